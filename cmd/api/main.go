@@ -2,36 +2,148 @@ package main
 
 import (
 	"banking-ledger-service/internal/handlers"
+	"banking-ledger-service/internal/interest"
+	"banking-ledger-service/internal/outbox"
 	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/schedule"
 	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/internal/worker"
+	"context"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// memoryWorkerLanes is the number of lanes of the in-process worker started
+// by the memory backend
+const memoryWorkerLanes = 8
+
+// memoryQueueCapacity is the number of messages the memory queue holds, and
+// so the most any lane of the in-process worker has to buffer
+const memoryQueueCapacity = 10000
+
 func main() {
+	defaultBackend := os.Getenv("BACKEND")
+	if defaultBackend == "" {
+		defaultBackend = "postgres"
+	}
+	backend := flag.String("backend", defaultBackend, `storage and queue backend: "postgres" or "memory"`)
+	flag.Parse()
+
 	// Load environment variables from .env file if available
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	var h *handlers.Handler
+	switch *backend {
+	case "postgres":
+		h = postgresHandler()
+	case "memory":
+		h = memoryHandler()
+	default:
+		log.Fatalf("Unknown backend %q", *backend)
+	}
+
+	// Set up HTTP handlers for account creation and transactions
+	http.HandleFunc("/accounts/create", h.CreateAccount)
+	http.HandleFunc("/accounts/balance", h.GetAccountBalance)
+	http.HandleFunc("GET /products", h.ListProducts)
+	http.HandleFunc("GET /products/{code}", h.GetProduct)
+	http.HandleFunc("POST /customers", h.CreateCustomer)
+	http.HandleFunc("GET /customers", h.ListCustomers)
+	http.HandleFunc("GET /customers/{id}", h.GetCustomer)
+	http.HandleFunc("PUT /customers/{id}", h.UpdateCustomer)
+	http.HandleFunc("DELETE /customers/{id}", h.DeleteCustomer)
+	http.HandleFunc("GET /customers/{id}/accounts", h.ListCustomerAccounts)
+	http.HandleFunc("GET /accounts/{id}/transactions", h.ListTransactions)
+	http.HandleFunc("/transactions/deposit", h.Deposit)
+	http.HandleFunc("/transactions/withdraw", h.Withdraw)
+	http.HandleFunc("/transactions/transfer", h.Transfer)
+	http.HandleFunc("POST /transactions/{id}/reverse", h.ReverseTransaction)
+	http.HandleFunc("GET /operations/{id}", h.GetOperation)
+	http.HandleFunc("POST /accounts/{id}/holds", h.PlaceHold)
+	http.HandleFunc("GET /holds/{id}", h.GetHold)
+	http.HandleFunc("POST /holds/{id}/capture", h.CaptureHold)
+	http.HandleFunc("POST /holds/{id}/release", h.ReleaseHold)
+	http.HandleFunc("POST /schedules", h.CreateSchedule)
+	http.HandleFunc("GET /schedules", h.ListSchedules)
+	http.HandleFunc("GET /schedules/{id}", h.GetSchedule)
+	http.HandleFunc("POST /schedules/{id}/pause", h.PauseSchedule)
+	http.HandleFunc("POST /schedules/{id}/resume", h.ResumeSchedule)
+	http.HandleFunc("POST /schedules/{id}/cancel", h.CancelSchedule)
+	http.HandleFunc("POST /interest-products", h.CreateInterestProduct)
+	http.HandleFunc("GET /interest-products", h.ListInterestProducts)
+	http.HandleFunc("GET /interest-products/{id}", h.GetInterestProduct)
+	http.HandleFunc("PUT /accounts/{id}/interest-product", h.SetInterestProduct)
+	http.HandleFunc("GET /accounts/{id}/interest-accruals", h.ListAccruals)
+	http.HandleFunc("PUT /accounts/{id}/type", h.SetAccountType)
+	http.HandleFunc("POST /accounts/{id}/freeze", h.FreezeAccount)
+	http.HandleFunc("POST /accounts/{id}/unfreeze", h.UnfreezeAccount)
+	http.HandleFunc("POST /accounts/{id}/activate", h.ActivateAccount)
+	http.HandleFunc("POST /accounts/{id}/close", h.CloseAccount)
+	http.HandleFunc("GET /accounts/{id}/events", h.ListAccountEvents)
+	http.HandleFunc("PUT /accounts/{id}/overdraft", h.SetOverdraft)
+	http.HandleFunc("DELETE /accounts/{id}/overdraft", h.RevokeOverdraft)
+	http.HandleFunc("GET /accounts/{id}/overdraft-accruals", h.ListOverdraftAccruals)
+	http.HandleFunc("POST /fee-rules", h.CreateFeeRule)
+	http.HandleFunc("GET /fee-rules", h.ListFeeRules)
+	http.HandleFunc("POST /fee-rules/{id}/deactivate", h.DeactivateFeeRule)
+	http.HandleFunc("GET /fees/preview", h.PreviewFees)
+
+	// Start the API server on port 8080
+	log.Println("API Server running on :8080")
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+
+// postgresHandler connects to PostgreSQL, MongoDB and the queue chosen by
+// QUEUE_BACKEND. Requests are applied by the separate worker process.
+func postgresHandler() *handlers.Handler {
 	// Initialize PostgreSQL database connection
 	storage.InitDB()
 
 	// Initialize MongoDB database connection
 	storage.InitMongoDB()
 
-	// Initialize RabbitMQ connection
-	queue.InitRabbitMQ()
+	// Publish requests written to the outbox
+	relay := outbox.Relay{Interval: time.Second, BatchSize: 100, ClaimTimeout: 10 * time.Minute}
+	switch backend := os.Getenv("QUEUE_BACKEND"); backend {
+	case "", "rabbitmq":
+		// Initialize RabbitMQ connection
+		queue.InitRabbitMQ()
+		relay.Publish = queue.PublishMessage
+	case "postgres":
+		relay.Publish = queue.NewPostgresQueue(storage.DB).PublishMessage
+	default:
+		log.Fatalf("Unknown queue backend %q", backend)
+	}
+	go relay.Run(context.Background())
 
-	// Set up HTTP handlers for account creation and transactions
-	http.HandleFunc("/accounts/create", handlers.CreateAccount)
-	http.HandleFunc("/accounts/balance", handlers.GetAccountBalance)
-	http.HandleFunc("/transactions/deposit", handlers.Deposit)
-	http.HandleFunc("/transactions/withdraw", handlers.Withdraw)
+	db := storage.Postgres{}
+	return handlers.New(db, db, db, db, db, db, db, db, db)
+}
 
-	// Start the API server on port 8080
-	log.Println("API Server running on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+// memoryHandler keeps all state in memory and runs the worker in this
+// process along with the scheduler and the interest engine, so the service
+// starts without any external dependencies
+func memoryHandler() *handlers.Handler {
+	q := queue.NewMemoryQueue(memoryQueueCapacity)
+	store := storage.NewMemory(q.PublishMessage)
+
+	messages, err := q.ConsumeMessages(0)
+	if err != nil {
+		log.Fatal("Failed to consume messages:", err)
+	}
+	go worker.NewProcessor(store, store, store).Run(messages, memoryWorkerLanes, memoryQueueCapacity)
+
+	scheduler := &schedule.Scheduler{Schedules: store, Publisher: store, Interval: time.Second, BatchSize: 100}
+	go scheduler.Run(context.Background())
+	go (&interest.Engine{Interest: store, Interval: time.Hour}).Run(context.Background())
+
+	log.Println("Using in-memory storage and queue, data is lost on exit")
+	return handlers.New(store, store, store, store, store, store, store, store, store)
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"banking-ledger-service/internal/interest"
+	"banking-ledger-service/internal/schedule"
+	"banking-ledger-service/internal/storage"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	storage.InitDB()
+
+	interval := envDuration("SCHEDULER_INTERVAL", 10*time.Second)
+	interestInterval := envDuration("INTEREST_INTERVAL", time.Hour)
+
+	log.Printf("Scheduler started, checking for due schedules every %s and for interest every %s", interval, interestInterval)
+
+	// Accrue and post interest for the days that have ended
+	db := storage.Postgres{}
+	go (&interest.Engine{Interest: db, Interval: interestInterval}).Run(context.Background())
+
+	// Accounts without customer activity for DORMANT_AFTER go dormant;
+	// dormancy is off unless it is set
+	if dormantAfter := envDuration("DORMANT_AFTER", 0); dormantAfter > 0 {
+		go markDormant(context.Background(), db, dormantAfter, interestInterval)
+	}
+
+	// Due occurrences are written to the outbox like API requests and
+	// relayed to the queue by the API
+	scheduler := &schedule.Scheduler{Schedules: db, Publisher: db, Interval: interval, BatchSize: 100}
+	scheduler.Run(context.Background())
+}
+
+// markDormant moves accounts without customer activity for after to dormant
+// every interval until ctx is cancelled
+func markDormant(ctx context.Context, accounts storage.AccountRepository, after, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := accounts.MarkDormant(time.Now().Add(-after))
+		if err != nil {
+			log.Println("Dormancy check failed:", err)
+		} else if n > 0 {
+			log.Printf("Marked %d accounts dormant", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// envDuration reads a positive duration setting, falling back to def
+func envDuration(name string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -3,77 +3,14 @@ package main
 import (
 	"banking-ledger-service/internal/queue"
 	"banking-ledger-service/internal/storage"
-	"encoding/json"
+	"banking-ledger-service/internal/worker"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
-	"github.com/rabbitmq/amqp091-go"
 )
 
-// ProcessTransaction handles messages from RabbitMQ
-func ProcessTransaction(msg amqp091.Delivery) {
-	// Parse message body
-	var data map[string]interface{}
-	err := json.Unmarshal(msg.Body, &data)
-	if err != nil {
-		log.Println("Failed to parse message:", err)
-		return
-	}
-
-	log.Println("Processing transaction:", data)
-
-	// Check transaction type
-	txType, ok := data["type"].(string)
-	if !ok || txType == "" {
-		log.Println("Invalid transaction type")
-		return
-	}
-
-	switch txType {
-	case "account_creation":
-		// Create account
-		name := data["name"].(string)
-		balance := data["balance"].(float64)
-		accountID, err := storage.CreateAccount(name, balance)
-		if err != nil {
-			log.Println("Account creation failed:", err)
-		} else {
-			log.Println("Account created successfully")
-			storage.LogTransactionToMongo(accountID, balance, "account_creation")
-		}
-	case "deposit":
-		// Deposit funds
-		accountID := int(data["account_id"].(float64))
-		amount := data["amount"].(float64)
-		if err := storage.UpdateBalance(accountID, amount, "deposit"); err != nil {
-			log.Println("Deposit failed:", err)
-		} else {
-			log.Println("Deposit successful")
-			storage.LogTransactionToMongo(accountID, amount, "deposit")
-		}
-	case "withdraw":
-		// Withdraw funds
-		accountID := int(data["account_id"].(float64))
-		amount := data["amount"].(float64)
-		account, err := storage.GetAccount(accountID)
-		if err != nil || account.Balance < amount {
-			log.Println("Withdrawal failed: Insufficient balance")
-			return
-		}
-		if err := storage.UpdateBalance(accountID, amount, "withdraw"); err != nil {
-			log.Println("Withdrawal failed:", err)
-		} else {
-			log.Println("Withdrawal successful")
-			storage.LogTransactionToMongo(accountID, amount, "withdraw")
-		}
-	default:
-		// Unknown transaction type
-		log.Println("Unknown transaction type:", txType)
-	}
-
-	msg.Ack(false)
-}
-
 func main() {
 	// Initialize storage and queue connections
 
@@ -84,16 +21,38 @@ func main() {
 
 	storage.InitDB()
 	storage.InitMongoDB()
-	queue.InitRabbitMQ()
 
-	log.Println("Worker started, waiting for messages...")
-
-	messages, err := queue.ConsumeMessages()
+	concurrency := envInt("WORKER_CONCURRENCY", 8)
+	prefetch := envInt("WORKER_PREFETCH", concurrency*4)
+
+	var messages <-chan queue.Delivery
+	var err error
+	switch backend := os.Getenv("QUEUE_BACKEND"); backend {
+	case "", "rabbitmq":
+		queue.InitRabbitMQ()
+		messages, err = queue.ConsumeMessages(prefetch)
+	case "postgres":
+		messages, err = queue.NewPostgresQueue(storage.DB).ConsumeMessages(prefetch)
+	default:
+		log.Fatalf("Unknown queue backend %q", backend)
+	}
 	if err != nil {
 		log.Fatal("Failed to consume messages:", err)
 	}
 
-	for msg := range messages {
-		go ProcessTransaction(msg)
+	log.Printf("Worker started with %d lanes and prefetch %d, waiting for messages...", concurrency, prefetch)
+
+	// Process each account's messages in order, different accounts in parallel
+	db := storage.Postgres{}
+	processor := worker.NewProcessor(db, db, db)
+	processor.Run(messages, concurrency, prefetch)
+}
+
+// envInt reads a positive integer setting, falling back to def
+func envInt(name string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return def
 	}
+	return v
 }
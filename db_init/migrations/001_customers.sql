@@ -0,0 +1,32 @@
+-- Moves a database created before customers existed onto the current
+-- schema. Each existing customer account becomes the only account of a new
+-- customer whose legal name is the account's name, which was unique until
+-- now. Fresh databases get the same tables from schema.sql.
+BEGIN;
+
+CREATE TABLE customers (
+    id SERIAL PRIMARY KEY,
+    legal_name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    address TEXT,
+    date_of_birth DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE accounts ADD COLUMN customer_id INT REFERENCES customers(id);
+
+INSERT INTO customers (legal_name)
+SELECT name FROM accounts WHERE NOT is_system ORDER BY id;
+
+UPDATE accounts SET customer_id = customers.id
+FROM customers
+WHERE NOT accounts.is_system AND customers.legal_name = accounts.name;
+
+ALTER TABLE accounts DROP CONSTRAINT accounts_name_key;
+ALTER TABLE accounts ADD UNIQUE (customer_id, name);
+ALTER TABLE accounts ADD CHECK (is_system OR customer_id IS NOT NULL);
+CREATE UNIQUE INDEX accounts_system_name_idx ON accounts (name) WHERE is_system;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Moves a database created before accounts had currencies onto the current
+-- schema. Existing accounts and their transactions were all in US dollars.
+-- Fresh databases get the same columns from schema.sql.
+BEGIN;
+
+ALTER TABLE accounts ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE transactions ADD COLUMN currency TEXT;
+UPDATE transactions SET currency = 'USD';
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+
+-- System accounts are now kept per currency, and the existing ones only
+-- ever took US dollars
+UPDATE accounts SET name = name || ':USD' WHERE is_system;
+
+COMMIT;
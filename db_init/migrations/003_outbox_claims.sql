@@ -0,0 +1,8 @@
+-- Moves a database created before outbox messages were claimed by the relay
+-- onto the current schema. Fresh databases get the same column from
+-- schema.sql.
+BEGIN;
+
+ALTER TABLE outbox ADD COLUMN locked_until TIMESTAMP;
+
+COMMIT;
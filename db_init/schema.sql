@@ -1,13 +1,285 @@
-CREATE TABLE accounts (
+-- Monetary columns hold integer minor units (cents) so amounts are exact
+
+-- Savings products. Rates are in basis points; tiers is a JSON array of
+-- {"min_balance", "annual_rate_bps"} bands above the base rate.
+CREATE TABLE interest_products (
     id SERIAL PRIMARY KEY,
     name TEXT UNIQUE NOT NULL,
-    balance DECIMAL(15,2) NOT NULL DEFAULT 0.00
+    annual_rate_bps INT NOT NULL CHECK (annual_rate_bps >= 0),
+    day_count TEXT NOT NULL CHECK (day_count IN ('actual/365', 'actual/360', 'actual/actual', '30/360')),
+    compounding TEXT NOT NULL CHECK (compounding IN ('daily', 'monthly', 'quarterly', 'annually')),
+    tiers JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- People who hold accounts. A customer may hold any number of accounts.
+CREATE TABLE customers (
+    id SERIAL PRIMARY KEY,
+    legal_name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    address TEXT,
+    date_of_birth DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE accounts (
+    id SERIAL PRIMARY KEY,
+    -- A label unique among the customer's accounts; system accounts are
+    -- looked up by name
+    name TEXT NOT NULL,
+    -- Every customer account belongs to a customer; system accounts to none
+    customer_id INT REFERENCES customers(id),
+    balance BIGINT NOT NULL DEFAULT 0,
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Frozen and dormant accounts take credits only; pending and closed
+    -- accounts take no transactions. See internal/lifecycle.
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('pending', 'active', 'frozen', 'dormant', 'closed')),
+    -- Catalog product whose rules the account follows. See internal/products.
+    product_code TEXT NOT NULL DEFAULT 'checking' CHECK (product_code IN ('checking', 'savings', 'business', 'escrow')),
+    -- ISO 4217 code of every amount on the account. Amounts keep two decimal
+    -- places whatever the currency; see internal/currency.
+    currency TEXT NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$'),
+    -- Selects the fee rules that apply to the account
+    account_type TEXT NOT NULL DEFAULT 'standard',
+    -- Interest accrues from interest_since for accounts with a product
+    interest_product_id INT REFERENCES interest_products(id),
+    interest_since DATE,
+    -- Debits may take the balance down to -overdraft_limit. The floor is
+    -- checked while the account row is locked rather than by a constraint:
+    -- overdraft interest is charged even past it, and a limit may be revoked
+    -- while the account is overdrawn.
+    overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0),
+    -- Overdraft interest accrues from overdraft_since while the rate is set
+    overdraft_rate_bps INT NOT NULL DEFAULT 0 CHECK (overdraft_rate_bps >= 0),
+    overdraft_since DATE,
+    UNIQUE (customer_id, name),
+    CHECK (is_system OR customer_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX accounts_system_name_idx ON accounts (name) WHERE is_system;
+
+-- System accounts that deposits are funded from, withdrawals are paid to,
+-- interest is paid from (and overdraft interest to) and fees are paid to.
+-- Each is kept per currency, so a balance never mixes currencies; the
+-- storage layer creates those for other currencies on first use.
+INSERT INTO accounts (name, is_system, currency) VALUES
+    ('system:cash_in:USD', TRUE, 'USD'), ('system:cash_out:USD', TRUE, 'USD'),
+    ('system:interest:USD', TRUE, 'USD'), ('system:fees:USD', TRUE, 'USD');
+
+-- Every change of an account's status, with who made it and why
+CREATE TABLE account_events (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts(id),
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE INDEX account_events_account_id_idx ON account_events (account_id, id);
+
+-- Double-entry journal. accounts.balance is the running sum of an account's
+-- postings and every entry's postings must sum to zero.
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    description TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES journal_entries(id),
+    account_id INT NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX postings_entry_id_idx ON postings (entry_id);
+CREATE INDEX postings_account_id_idx ON postings (account_id);
+
+CREATE FUNCTION check_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    entry INT := COALESCE(NEW.entry_id, OLD.entry_id);
+BEGIN
+    IF (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE entry_id = entry) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % does not balance', entry;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Checked at commit so that all postings of an entry can be inserted first
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT OR UPDATE OR DELETE ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_entry_balanced();
+
 CREATE TABLE transactions (
     id SERIAL PRIMARY KEY,
     account_id INT REFERENCES accounts(id),
-    amount DECIMAL(15,2) NOT NULL,
-    type TEXT CHECK (type IN ('deposit', 'withdraw', 'account_creation')),
+    amount BIGINT NOT NULL,
+    -- The account's currency, recorded with every transaction
+    currency TEXT NOT NULL,
+    type TEXT CHECK (type IN ('deposit', 'withdraw', 'account_creation', 'transfer_in', 'transfer_out', 'capture', 'reversal', 'interest', 'fee', 'overdraft_interest', 'payout')),
+    entry_id INT REFERENCES journal_entries(id),
+    balance_after BIGINT,
+    -- A reversal points at the transaction it undoes; UNIQUE prevents a
+    -- transaction from being reversed twice
+    reversal_of INT UNIQUE REFERENCES transactions(id),
+    -- A fee points at the deposit or withdrawal it was charged on
+    fee_for INT REFERENCES transactions(id),
+    reason TEXT,
+    actor TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX transactions_account_id_idx ON transactions (account_id, id);
+
+-- Fees charged on deposits and withdrawals. See models.FeeRule.
+CREATE TABLE fee_rules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('deposit', 'withdraw')),
+    account_type TEXT,
+    min_amount BIGINT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    free_per_month INT NOT NULL DEFAULT 0 CHECK (free_per_month >= 0),
+    flat_fee BIGINT NOT NULL DEFAULT 0 CHECK (flat_fee >= 0),
+    rate_bps INT NOT NULL DEFAULT 0 CHECK (rate_bps >= 0),
+    max_fee BIGINT CHECK (max_fee >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Interest earned on each day's closing balance, in millionths of a cent.
+-- Accruals are posted together by an interest transaction once their
+-- compounding period has ended.
+CREATE TABLE interest_accruals (
+    account_id INT NOT NULL REFERENCES accounts(id),
+    accrual_date DATE NOT NULL,
+    product_id INT NOT NULL REFERENCES interest_products(id),
+    balance BIGINT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    transaction_id INT REFERENCES transactions(id),
+    PRIMARY KEY (account_id, accrual_date)
+);
+
+CREATE INDEX interest_accruals_unposted_idx ON interest_accruals (account_id, accrual_date) WHERE transaction_id IS NULL;
+
+-- Interest charged on each day's negative closing balance, in millionths of
+-- a cent. Accruals are charged together by an overdraft_interest transaction
+-- once their month has ended.
+CREATE TABLE overdraft_accruals (
+    account_id INT NOT NULL REFERENCES accounts(id),
+    accrual_date DATE NOT NULL,
+    balance BIGINT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    transaction_id INT REFERENCES transactions(id),
+    PRIMARY KEY (account_id, accrual_date)
+);
+
+CREATE INDEX overdraft_accruals_unposted_idx ON overdraft_accruals (account_id, accrual_date) WHERE transaction_id IS NULL;
+
+-- Funds reserved on an account without moving them yet, such as card
+-- authorizations. Active holds that have not expired are subtracted from the
+-- available balance; a capture debits the account and ends the hold.
+CREATE TABLE holds (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    reference TEXT,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'captured', 'released')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX holds_active_idx ON holds (account_id, expires_at) WHERE status = 'active';
+
+-- Standing orders. The scheduler queues each occurrence at next_run_at with
+-- an idempotency key derived from the schedule and the occurrence time.
+CREATE TABLE schedules (
+    id SERIAL PRIMARY KEY,
+    type TEXT NOT NULL CHECK (type IN ('deposit', 'withdraw', 'transfer')),
+    account_id INT NOT NULL REFERENCES accounts(id),
+    to_account_id INT REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    cron TEXT,
+    interval_seconds BIGINT CHECK (interval_seconds > 0),
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'cancelled', 'completed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((cron IS NULL) <> (interval_seconds IS NULL)),
+    CHECK ((type = 'transfer') = (to_account_id IS NOT NULL))
+);
+
+CREATE INDEX schedules_account_id_idx ON schedules (account_id);
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE status = 'active';
+
+CREATE TABLE operations (
+    id SERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'processing', 'succeeded', 'failed')),
+    reason TEXT,
+    account_id INT REFERENCES accounts(id),
+    idempotency_key TEXT UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Idempotency keys applied by the worker, written in the same transaction as
+-- the balance change they guard
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    account_id INT REFERENCES accounts(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
+
+-- Transactional outbox: requests accepted by the API, written alongside their
+-- operation and published to RabbitMQ by the relay
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    -- A relay publishing the message hides it from other relays until then
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX outbox_unsent_idx ON outbox (id) WHERE sent_at IS NULL;
+
+-- Job queue used instead of RabbitMQ when QUEUE_BACKEND=postgres. Consumers
+-- claim ready jobs with SKIP LOCKED and hide them until locked_until; failed
+-- jobs become available again after a backoff or are marked dead.
+CREATE TABLE jobs (
+    id BIGSERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    dead_at TIMESTAMP
+);
+
+CREATE INDEX jobs_ready_idx ON jobs (available_at, id) WHERE dead_at IS NULL;
+
+-- Wake up listening consumers as soon as jobs are queued
+CREATE FUNCTION notify_jobs() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('jobs', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_notify
+    AFTER INSERT ON jobs
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_jobs();
@@ -0,0 +1,45 @@
+// Package currency checks amounts against the precision of the ISO 4217
+// currencies accounts may hold, which models.Places lists.
+//
+// Amounts are models.Money, which keeps two decimal places, so currencies
+// with three minor-unit digits, such as KWD, cannot be held.
+package currency
+
+import (
+	"banking-ledger-service/internal/models"
+	"fmt"
+)
+
+// Default is the currency of accounts opened without one
+const Default = "USD"
+
+// Supported reports whether accounts may hold code
+func Supported(code string) bool {
+	_, ok := models.Places(code)
+	return ok
+}
+
+// Unit returns the smallest amount of code, such as 0.01 USD or 1 JPY
+func Unit(code string) models.Money {
+	if places, ok := models.Places(code); ok && places == 0 {
+		return 100
+	}
+	return 1
+}
+
+// CheckAmount reports why amount cannot be held in code
+func CheckAmount(code string, amount models.Money) error {
+	if !Supported(code) {
+		return fmt.Errorf("unsupported currency %q", code)
+	}
+	if amount%Unit(code) != 0 {
+		return fmt.Errorf("%s amounts must be whole", code)
+	}
+	return nil
+}
+
+// Round rounds a non-negative amount to the nearest unit of code
+func Round(code string, amount models.Money) models.Money {
+	unit := Unit(code)
+	return (amount + unit/2) / unit * unit
+}
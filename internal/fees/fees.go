@@ -0,0 +1,65 @@
+// Package fees works out the fees charged on deposits and withdrawals.
+package fees
+
+import (
+	"banking-ledger-service/internal/models"
+	"errors"
+)
+
+// Validate rejects rules for unsupported transaction types or with negative
+// amounts
+func Validate(r *models.FeeRule) error {
+	if r.Name == "" {
+		return errors.New("rule name is required")
+	}
+	if r.TransactionType != "deposit" && r.TransactionType != "withdraw" {
+		return errors.New("transaction type must be deposit or withdraw")
+	}
+	if r.MinAmount < 0 || r.FlatFee < 0 || r.RateBps < 0 || r.FreePerMonth < 0 || r.MaxFee != nil && *r.MaxFee < 0 {
+		return errors.New("amounts, rates and counts must not be negative")
+	}
+	if r.FlatFee == 0 && r.RateBps == 0 {
+		return errors.New("a flat fee or a rate is required")
+	}
+	return nil
+}
+
+// Evaluate returns the fees the active rules charge on a transaction of
+// txType and amount, made on an account of accountType that already made
+// monthCount transactions of txType this calendar month
+func Evaluate(rules []models.FeeRule, txType, accountType string, amount models.Money, monthCount int) []models.FeeCharge {
+	charges := []models.FeeCharge{}
+	for _, r := range rules {
+		switch {
+		case !r.Active,
+			r.TransactionType != txType,
+			r.AccountType != "" && r.AccountType != accountType,
+			amount < r.MinAmount,
+			monthCount < r.FreePerMonth:
+			continue
+		}
+
+		fee := r.FlatFee + percentage(amount, r.RateBps)
+		if r.MaxFee != nil && fee > *r.MaxFee {
+			fee = *r.MaxFee
+		}
+		if fee > 0 {
+			charges = append(charges, models.FeeCharge{RuleID: r.ID, Name: r.Name, Amount: fee})
+		}
+	}
+	return charges
+}
+
+// Total sums the charged fees
+func Total(charges []models.FeeCharge) models.Money {
+	var total models.Money
+	for _, c := range charges {
+		total += c.Amount
+	}
+	return total
+}
+
+// percentage returns rateBps of amount rounded to the nearest cent
+func percentage(amount models.Money, rateBps int) models.Money {
+	return (amount*models.Money(rateBps) + 5000) / 10000
+}
@@ -1,61 +1,82 @@
 package handlers
 
 import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/messages"
 	"banking-ledger-service/internal/models"
-	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/products"
 	"banking-ledger-service/internal/storage"
-	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 )
 
 // CreateAccount API handler
-func CreateAccount(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	var acc models.Account
 	if err := json.NewDecoder(r.Body).Decode(&acc); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	// Check if account already exists
-	var exists bool
-	err := storage.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM accounts WHERE name=$1)", acc.Name).Scan(&exists)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// The product must exist and the opening balance meet its minimum
+	code := acc.ProductCode
+	if code == "" {
+		code = products.Default
+	}
+	product, ok := products.Get(code)
+	if !ok {
+		http.Error(w, "Unknown product", http.StatusBadRequest)
 		return
 	}
-	if exists {
-		http.Error(w, "Account already exists", http.StatusConflict)
+	if err := products.CheckOpening(product, acc.Balance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Accounts open in the product's currency unless another is requested
+	if acc.Currency == "" {
+		acc.Currency = product.Currency
+	}
+	if err := currency.CheckAmount(acc.Currency, acc.Balance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Prepare the transaction message as JSON string
-	messageData := map[string]interface{}{
-		"type":    "account_creation",
-		"name":    acc.Name,
-		"balance": acc.Balance,
+	// Answer retries of an already accepted request with its original outcome
+	if h.replayOperation(w, r, "account_creation", "Account creation request sent to queue") {
+		return
 	}
 
-	messageBytes, err := json.Marshal(messageData) // Convert to JSON string
+	// Check if account already exists: among the customer's accounts, or
+	// among all accounts when a new customer is to be created for it
+	exists, err := h.accountExists(acc)
 	if err != nil {
-		http.Error(w, "Failed to serialize message", http.StatusInternalServerError)
+		customerError(w, err)
+		return
+	}
+	if exists {
+		http.Error(w, "Account already exists", http.StatusConflict)
 		return
 	}
-	messageString := string(messageBytes) // Convert bytes to string
 
-	// Send message to RabbitMQ
-	err = queue.PublishMessage(messageString)
+	// Prepare the message for the worker
+	msg := messages.AccountCreation{CustomerID: acc.CustomerID, Name: acc.Name, ProductCode: acc.ProductCode, Currency: acc.Currency, Balance: acc.Balance}
+
+	// Record the operation and queue it for the worker
+	op, err := h.enqueue(w, r, msg)
 	if err != nil {
-		http.Error(w, "Failed to queue account creation", http.StatusInternalServerError)
+		queueError(w, err, "Failed to queue account creation")
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"message": "Account creation request sent to queue"})
+	// Respond to client
+	writeOperation(w, "Account creation request sent to queue", op)
 }
 
 // GetAccount API handler
-func GetAccountBalance(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
 	accID := r.URL.Query().Get("id")
 	if accID == "" {
 		http.Error(w, "Account ID is required", http.StatusBadRequest)
@@ -70,7 +91,7 @@ func GetAccountBalance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Ensure account exists
-	account, err := storage.GetAccount(id)
+	account, err := h.Accounts.GetAccount(id)
 	if err != nil {
 		http.Error(w, "Account not found", http.StatusNotFound)
 		return
@@ -78,3 +99,43 @@ func GetAccountBalance(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(account)
 }
+
+// accountExists reports whether the customer already holds an account named
+// like the one to be created. Without a customer the account gets a new one,
+// so its name is never taken.
+func (h *Handler) accountExists(acc models.Account) (bool, error) {
+	if acc.CustomerID == nil {
+		return false, nil
+	}
+
+	accounts, err := h.Customers.ListCustomerAccounts(*acc.CustomerID)
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range accounts {
+		if existing.Name == acc.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// writeAccount responds with the account's current state
+func (h *Handler) writeAccount(w http.ResponseWriter, id int) {
+	acc, err := h.Accounts.GetAccount(id)
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(acc)
+}
+
+// accountError maps account lookup errors to responses
+func accountError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
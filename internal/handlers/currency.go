@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/models"
+	"net/http"
+)
+
+// allowsCurrency rejects with 400 a transaction in another currency than the
+// account's, when code is given, or an amount its currency cannot hold
+func allowsCurrency(w http.ResponseWriter, acc *models.Account, code string, amount models.Money) bool {
+	if code != "" && code != acc.Currency {
+		http.Error(w, "Account holds "+acc.Currency+", not "+code, http.StatusBadRequest)
+		return false
+	}
+	if err := currency.CheckAmount(acc.Currency, amount); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// sameCurrency rejects with 400 a transfer between accounts in different
+// currencies
+func sameCurrency(w http.ResponseWriter, from, to *models.Account) bool {
+	if from.Currency != to.Currency {
+		http.Error(w, "Cannot transfer "+from.Currency+" to a "+to.Currency+" account", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
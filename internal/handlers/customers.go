@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CreateCustomer API handler
+func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	customer, ok := decodeCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.Customers.CreateCustomer(customer)
+	if err != nil {
+		customerError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListCustomers API handler
+func (h *Handler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.Customers.ListCustomers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(customers)
+}
+
+// GetCustomer API handler
+func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.Customers.GetCustomer(id)
+	if err != nil {
+		customerError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(customer)
+}
+
+// UpdateCustomer API handler. The body replaces all of the customer's
+// details; fields left out are cleared.
+func (h *Handler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+	customer, ok := decodeCustomer(w, r)
+	if !ok {
+		return
+	}
+	customer.ID = id
+
+	updated, err := h.Customers.UpdateCustomer(customer)
+	if err != nil {
+		customerError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteCustomer API handler. Only customers without accounts can be
+// deleted.
+func (h *Handler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Customers.DeleteCustomer(id); err != nil {
+		customerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCustomerAccounts API handler for every account a customer holds
+func (h *Handler) ListCustomerAccounts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := h.Customers.ListCustomerAccounts(id)
+	if err != nil {
+		customerError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// decodeCustomer reads and validates a customer's details
+func decodeCustomer(w http.ResponseWriter, r *http.Request) (models.Customer, bool) {
+	var c models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return c, false
+	}
+
+	c.LegalName = strings.TrimSpace(c.LegalName)
+	if c.LegalName == "" {
+		http.Error(w, "Legal name is required", http.StatusBadRequest)
+		return c, false
+	}
+	if c.Email != "" && !strings.Contains(c.Email, "@") {
+		http.Error(w, "Invalid email", http.StatusBadRequest)
+		return c, false
+	}
+	if c.DateOfBirth != "" {
+		born, err := time.Parse(time.DateOnly, c.DateOfBirth)
+		if err != nil || born.After(time.Now()) {
+			http.Error(w, "Date of birth must be a past date as YYYY-MM-DD", http.StatusBadRequest)
+			return c, false
+		}
+	}
+	return c, true
+}
+
+// customerError maps customer storage errors to responses
+func customerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Customer not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrCustomerHasAccounts):
+		http.Error(w, "Customer holds accounts", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,55 +1,50 @@
 package handlers
 
 import (
+	"banking-ledger-service/internal/messages"
 	"banking-ledger-service/internal/models"
-	"banking-ledger-service/internal/queue"
-	"banking-ledger-service/internal/storage"
 	"encoding/json"
 	"net/http"
 )
 
 // Deposit API handler
-func Deposit(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
 	var tx models.Transaction
 	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
+	// Answer retries of an already accepted request with its original outcome
+	if h.replayOperation(w, r, "deposit", "Deposit request sent to queue") {
+		return
+	}
+
 	if tx.Amount <= 0 { // Prevent negative deposits
 		http.Error(w, "Deposit amount must be greater than zero", http.StatusBadRequest)
 		return
 	}
 
-	// Ensure account exists
-	_, err := storage.GetAccount(tx.AccountID)
+	// Ensure account exists and takes deposits
+	account, err := h.Accounts.GetAccount(tx.AccountID)
 	if err != nil {
 		http.Error(w, "Account not found", http.StatusNotFound)
 		return
 	}
-
-	// Prepare message as JSON string
-	messageData := map[string]interface{}{
-		"type":       "deposit",
-		"account_id": tx.AccountID,
-		"amount":     tx.Amount,
-	}
-
-	// Convert to JSON string
-	messageBytes, err := json.Marshal(messageData)
-	if err != nil {
-		http.Error(w, "Failed to serialize message", http.StatusInternalServerError)
+	if !allowsTransaction(w, account, false, "Account") || !allowsProduct(w, account, "deposit", tx.Amount) || !allowsCurrency(w, account, tx.Currency, tx.Amount) {
 		return
 	}
-	messageString := string(messageBytes)
 
-	// Send to RabbitMQ
-	err = queue.PublishMessage(messageString)
+	// Prepare the message for the worker
+	msg := messages.Deposit{AccountID: tx.AccountID, Amount: tx.Amount, Currency: tx.Currency}
+
+	// Record the operation and queue it for the worker
+	op, err := h.enqueue(w, r, msg)
 	if err != nil {
-		http.Error(w, "Failed to queue deposit transaction", http.StatusInternalServerError)
+		queueError(w, err, "Failed to queue deposit transaction")
 		return
 	}
 
 	// Respond to client
-	json.NewEncoder(w).Encode(map[string]string{"message": "Deposit request sent to queue"})
+	writeOperation(w, "Deposit request sent to queue", op)
 }
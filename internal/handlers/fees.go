@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/fees"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// CreateFeeRule API handler
+func (h *Handler) CreateFeeRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.FeeRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := fees.Validate(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.Fees.CreateFeeRule(rule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListFeeRules API handler
+func (h *Handler) ListFeeRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.Fees.ListFeeRules()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rules)
+}
+
+// DeactivateFeeRule API handler. Fees already charged by the rule stay.
+func (h *Handler) DeactivateFeeRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid fee rule ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.Fees.DeactivateFeeRule(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Fee rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rule)
+}
+
+// PreviewFees API handler. It reports the fees a deposit or withdrawal given
+// by the account_id, type and amount query parameters would be charged if
+// it were processed now.
+func (h *Handler) PreviewFees(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	accountID, err := strconv.Atoi(params.Get("account_id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	txType := params.Get("type")
+	if txType != "deposit" && txType != "withdraw" {
+		http.Error(w, "Type must be deposit or withdraw", http.StatusBadRequest)
+		return
+	}
+	amount, err := models.ParseMoney(params.Get("amount"))
+	if err != nil || amount <= 0 {
+		http.Error(w, "Amount must be greater than zero", http.StatusBadRequest)
+		return
+	}
+
+	charges, err := h.Fees.PreviewFees(accountID, txType, amount)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"fees": charges, "total": fees.Total(charges)})
+}
+
+// SetAccountType API handler. The type selects the fee rules that apply.
+func (h *Handler) SetAccountType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		AccountType string `json:"account_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AccountType == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Accounts.SetAccountType(id, req.AccountType); err != nil {
+		accountError(w, err)
+		return
+	}
+	h.writeAccount(w, id)
+}
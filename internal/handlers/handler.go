@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+)
+
+// Handler serves the HTTP API using injected repositories and publisher
+type Handler struct {
+	Accounts     storage.AccountRepository
+	Customers    storage.CustomerRepository
+	Transactions storage.TransactionRepository
+	Operations   storage.OperationRepository
+	Holds        storage.HoldRepository
+	Schedules    storage.ScheduleRepository
+	Interest     storage.InterestRepository
+	Fees         storage.FeeRepository
+	Publisher    queue.Publisher
+}
+
+// New creates a Handler backed by the given repositories and publisher
+func New(accounts storage.AccountRepository, customers storage.CustomerRepository, transactions storage.TransactionRepository, operations storage.OperationRepository, holds storage.HoldRepository, schedules storage.ScheduleRepository, interest storage.InterestRepository, fees storage.FeeRepository, publisher queue.Publisher) *Handler {
+	return &Handler{
+		Accounts:     accounts,
+		Customers:    customers,
+		Transactions: transactions,
+		Operations:   operations,
+		Holds:        holds,
+		Schedules:    schedules,
+		Interest:     interest,
+		Fees:         fees,
+		Publisher:    publisher,
+	}
+}
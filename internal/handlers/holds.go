@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultHoldExpiry applies when a hold is placed without expires_at
+const defaultHoldExpiry = 7 * 24 * time.Hour
+
+// PlaceHold API handler. Holds are placed synchronously so the caller knows
+// at once whether the funds were reserved.
+func (h *Handler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount    models.Money `json:"amount"`
+		Reference string       `json:"reference"`
+		ExpiresAt *time.Time   `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.Amount <= 0 {
+		http.Error(w, "Hold amount must be greater than zero", http.StatusBadRequest)
+		return
+	}
+	expiresAt := time.Now().Add(defaultHoldExpiry)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(time.Now()) {
+		http.Error(w, "Hold expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.Holds.PlaceHold(accountID, req.Amount, req.Reference, expiresAt)
+	if err != nil {
+		holdError(w, err, "Account not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(hold)
+}
+
+// GetHold API handler
+func (h *Handler) GetHold(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.Holds.GetHold(id)
+	if err != nil {
+		holdError(w, err, "Hold not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(hold)
+}
+
+// CaptureHold API handler. The amount is optional; without it the full hold
+// is captured, and after a partial capture the rest of the hold is released.
+func (h *Handler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount models.Money `json:"amount"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Amount < 0 {
+		http.Error(w, "Capture amount must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.Holds.CaptureHold(id, req.Amount)
+	if err != nil {
+		holdError(w, err, "Hold not found")
+		return
+	}
+	h.Transactions.LogTransaction(hold.AccountID, hold.CapturedAmount, "capture")
+
+	json.NewEncoder(w).Encode(hold)
+}
+
+// ReleaseHold API handler
+func (h *Handler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.Holds.ReleaseHold(id)
+	if err != nil {
+		holdError(w, err, "Hold not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(hold)
+}
+
+// holdError maps hold storage errors to responses
+func holdError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, storage.ErrInsufficientFunds):
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrCaptureExceedsHold):
+		http.Error(w, "Capture amount exceeds hold", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrHoldNotActive):
+		http.Error(w, "Hold is not active", http.StatusConflict)
+	case errors.Is(err, storage.ErrAccountNotActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrProductRule), errors.Is(err, storage.ErrInvalidAmount),
+		errors.Is(err, storage.ErrCurrencyMismatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
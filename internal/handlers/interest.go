@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/interest"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CreateInterestProduct API handler
+func (h *Handler) CreateInterestProduct(w http.ResponseWriter, r *http.Request) {
+	var product models.InterestProduct
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := interest.Validate(&product); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.Interest.CreateInterestProduct(product)
+	if err != nil {
+		interestError(w, err, "Interest product not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListInterestProducts API handler
+func (h *Handler) ListInterestProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.Interest.ListInterestProducts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(products)
+}
+
+// GetInterestProduct API handler
+func (h *Handler) GetInterestProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.Interest.GetInterestProduct(id)
+	if err != nil {
+		interestError(w, err, "Interest product not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(product)
+}
+
+// SetInterestProduct API handler. The account starts accruing under the
+// product from today; a null product_id stops interest, and what was already
+// accrued is still posted.
+func (h *Handler) SetInterestProduct(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ProductID *int `json:"product_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ProductID != nil {
+		if _, err := h.Interest.GetInterestProduct(*req.ProductID); err != nil {
+			interestError(w, err, "Interest product not found")
+			return
+		}
+	}
+	if err := h.Interest.SetInterestProduct(accountID, req.ProductID, time.Now()); err != nil {
+		interestError(w, err, "Account not found")
+		return
+	}
+
+	acc, err := h.Accounts.GetAccount(accountID)
+	if err != nil {
+		interestError(w, err, "Account not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(acc)
+}
+
+// ListAccruals API handler for the daily interest accrued on an account
+func (h *Handler) ListAccruals(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	accruals, err := h.Interest.ListAccruals(accountID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(accruals)
+}
+
+// interestError maps interest storage errors to responses
+func interestError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, storage.ErrProductExists):
+		http.Error(w, "Interest product already exists", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
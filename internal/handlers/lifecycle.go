@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/lifecycle"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// statusRequest is the body of the account status endpoints
+type statusRequest struct {
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+	Payout bool   `json:"payout"` // Close only: pay a positive balance out
+}
+
+// FreezeAccount API handler. A frozen account takes credits but no debits.
+func (h *Handler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, lifecycle.Freeze)
+}
+
+// UnfreezeAccount API handler
+func (h *Handler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, lifecycle.Unfreeze)
+}
+
+// ActivateAccount API handler for pending and dormant accounts
+func (h *Handler) ActivateAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, lifecycle.Activate)
+}
+
+// CloseAccount API handler. The balance must be zero unless payout is set,
+// in which case a positive balance is paid out.
+func (h *Handler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeStatusRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Accounts.CloseAccount(id, req.Reason, req.Actor, req.Payout); err != nil {
+		accountStatusError(w, err)
+		return
+	}
+	h.writeAccount(w, id)
+}
+
+// ListAccountEvents API handler for an account's status history
+func (h *Handler) ListAccountEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.Accounts.ListAccountEvents(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(events)
+}
+
+func (h *Handler) changeAccountStatus(w http.ResponseWriter, r *http.Request, action string) {
+	id, req, ok := decodeStatusRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Accounts.ChangeAccountStatus(id, action, req.Reason, req.Actor); err != nil {
+		accountStatusError(w, err)
+		return
+	}
+	h.writeAccount(w, id)
+}
+
+func decodeStatusRequest(w http.ResponseWriter, r *http.Request) (int, statusRequest, bool) {
+	var req statusRequest
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return 0, req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return 0, req, false
+	}
+	if req.Reason == "" || req.Actor == "" {
+		http.Error(w, "Reason and actor are required", http.StatusBadRequest)
+		return 0, req, false
+	}
+	return id, req, true
+}
+
+// allowsTransaction rejects with 409 a debit, or a credit when debit is
+// false, that the account's status does not allow. role names the account
+// in the response.
+func allowsTransaction(w http.ResponseWriter, acc *models.Account, debit bool, role string) bool {
+	if debit && lifecycle.AllowsDebit(acc.Status) || !debit && lifecycle.AllowsCredit(acc.Status) {
+		return true
+	}
+	http.Error(w, role+" is "+acc.Status, http.StatusConflict)
+	return false
+}
+
+// accountStatusError maps account status change errors to responses
+func accountStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Account not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrInvalidTransition), errors.Is(err, storage.ErrBalanceNotZero):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// GetOperation API handler
+func (h *Handler) GetOperation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid operation ID", http.StatusBadRequest)
+		return
+	}
+
+	op, err := h.Operations.GetOperation(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Operation not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(op)
+}
+
+// IdempotencyKeyHeader lets clients retry a request without applying it twice
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+var errIdempotencyKeyReused = errors.New("Idempotency-Key was already used for a different request")
+
+// replayOperation answers a retried request with the operation originally
+// created for its idempotency key. It reports whether a response was written.
+func (h *Handler) replayOperation(w http.ResponseWriter, r *http.Request, opType string, message string) bool {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return false
+	}
+
+	op, err := h.Operations.GetOperationByIdempotencyKey(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	if op.Type != opType {
+		http.Error(w, errIdempotencyKeyReused.Error(), http.StatusUnprocessableEntity)
+		return true
+	}
+
+	writeOperation(w, message, op)
+	return true
+}
+
+// CorrelationIDHeader ties a queued message back to the request that sent
+// it; a new ID is generated when the client does not send one
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// enqueue records a queued operation for msg and hands it to the publisher
+// for the worker, returning the operation. If another request with the same
+// idempotency key won the race, its operation is returned and nothing is
+// queued.
+func (h *Handler) enqueue(w http.ResponseWriter, r *http.Request, msg messages.Message) (*models.Operation, error) {
+	env, err := messages.New(msg, r.Header.Get(CorrelationIDHeader))
+	if err != nil {
+		return nil, err
+	}
+	w.Header().Set(CorrelationIDHeader, env.CorrelationID)
+
+	opType := msg.Type()
+	key := r.Header.Get(IdempotencyKeyHeader)
+	op, created, err := h.Publisher.Publish(opType, key, env)
+	if err != nil {
+		return nil, err
+	}
+	if !created && op.Type != opType {
+		return nil, errIdempotencyKeyReused
+	}
+	return op, nil
+}
+
+// queueError reports a failed enqueue, distinguishing reused idempotency keys
+// and invalid messages
+func queueError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, errIdempotencyKeyReused) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, messages.ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// writeOperation responds with the operation tracking a queued request
+func writeOperation(w http.ResponseWriter, message string, op *models.Operation) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      message,
+		"operation_id": op.ID,
+		"status":       op.Status,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetOverdraft API handler. It sets how far below zero the account may go
+// and, when rate_bps is given, the annual rate charged while it is negative.
+func (h *Handler) SetOverdraft(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Limit   *models.Money `json:"limit"`
+		RateBps *int          `json:"rate_bps"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Limit == nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if *req.Limit < 0 || req.RateBps != nil && *req.RateBps < 0 {
+		http.Error(w, "Limit and rate must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	h.setOverdraft(w, id, *req.Limit, req.RateBps)
+}
+
+// RevokeOverdraft API handler. The limit drops to zero so no further debits
+// are allowed while the balance is negative; the rate is kept so an
+// overdrawn balance goes on accruing interest until it is repaid.
+func (h *Handler) RevokeOverdraft(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	h.setOverdraft(w, id, 0, nil)
+}
+
+func (h *Handler) setOverdraft(w http.ResponseWriter, id int, limit models.Money, rateBps *int) {
+	if err := h.Accounts.SetOverdraft(id, limit, rateBps, time.Now()); err != nil {
+		accountError(w, err)
+		return
+	}
+	h.writeAccount(w, id)
+}
+
+// ListOverdraftAccruals API handler for the daily overdraft interest accrued
+// on an account
+func (h *Handler) ListOverdraftAccruals(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	accruals, err := h.Interest.ListOverdraftAccruals(accountID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(accruals)
+}
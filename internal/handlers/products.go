@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
+	"encoding/json"
+	"net/http"
+)
+
+// ListProducts API handler for the account catalog
+func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(products.List())
+}
+
+// GetProduct API handler
+func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := products.Get(r.PathValue("code"))
+	if !ok {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(product)
+}
+
+// allowsProduct rejects with 400 a transaction the account's product does
+// not allow. The overdraft limit does not count toward the minimum balance.
+// Monthly withdrawal limits depend on the account's history and are left to
+// the worker.
+func allowsProduct(w http.ResponseWriter, acc *models.Account, txType string, amount models.Money) bool {
+	product, ok := products.Get(acc.ProductCode)
+	if !ok {
+		http.Error(w, "Unknown product "+acc.ProductCode, http.StatusInternalServerError)
+		return false
+	}
+	if err := products.Check(product, txType, amount, acc.AvailableBalance-acc.OverdraftLimit, 0); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
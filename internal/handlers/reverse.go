@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ReverseTransaction API handler. The reversal is queued like other balance
+// changes; the worker re-checks everything under a row lock.
+func (h *Handler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+		Actor  string `json:"actor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Answer retries of an already accepted request with its original outcome
+	if h.replayOperation(w, r, "reversal", "Reversal request sent to queue") {
+		return
+	}
+
+	if req.Reason == "" || req.Actor == "" {
+		http.Error(w, "Reason and actor are required", http.StatusBadRequest)
+		return
+	}
+
+	// Ensure the transaction exists and can still be reversed
+	original, err := h.Transactions.GetTransaction(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if original.Type != "deposit" && original.Type != "withdraw" {
+		http.Error(w, "Only deposits and withdrawals can be reversed", http.StatusBadRequest)
+		return
+	}
+	if original.ReversedBy != nil {
+		http.Error(w, "Transaction already reversed", http.StatusConflict)
+		return
+	}
+
+	// Prepare the message for the worker
+	msg := messages.Reversal{TransactionID: id, AccountID: original.AccountID, Reason: req.Reason, Actor: req.Actor}
+
+	// Record the operation and queue it for the worker
+	op, err := h.enqueue(w, r, msg)
+	if err != nil {
+		queueError(w, err, "Failed to queue reversal")
+		return
+	}
+
+	// Respond to client
+	writeOperation(w, "Reversal request sent to queue", op)
+}
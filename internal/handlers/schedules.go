@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/schedule"
+	"banking-ledger-service/internal/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CreateSchedule API handler. Exactly one of cron and interval_seconds sets
+// how often the operation repeats; start_at defaults to now and end_at is
+// optional.
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type            string       `json:"type"`
+		AccountID       int          `json:"account_id"`
+		ToAccountID     *int         `json:"to_account_id"`
+		Amount          models.Money `json:"amount"`
+		Cron            string       `json:"cron"`
+		IntervalSeconds int64        `json:"interval_seconds"`
+		StartAt         *time.Time   `json:"start_at"`
+		EndAt           *time.Time   `json:"end_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	sched := models.Schedule{
+		Type:            req.Type,
+		AccountID:       req.AccountID,
+		Amount:          req.Amount,
+		Cron:            req.Cron,
+		IntervalSeconds: req.IntervalSeconds,
+		StartAt:         now,
+		EndAt:           req.EndAt,
+		Status:          models.ScheduleActive,
+	}
+	if req.StartAt != nil {
+		sched.StartAt = req.StartAt.UTC().Truncate(time.Second)
+	}
+
+	switch req.Type {
+	case messages.TypeDeposit, messages.TypeWithdraw:
+		if req.ToAccountID != nil {
+			http.Error(w, "to_account_id is only used by transfers", http.StatusBadRequest)
+			return
+		}
+	case messages.TypeTransfer:
+		sched.ToAccountID = req.ToAccountID
+	default:
+		http.Error(w, "Schedule type must be deposit, withdraw or transfer", http.StatusBadRequest)
+		return
+	}
+	if err := schedule.MessageOf(&sched).Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	next, err := schedule.NextRun(&sched, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if next == nil {
+		http.Error(w, "Schedule has no occurrences before it ends", http.StatusBadRequest)
+		return
+	}
+	sched.NextRunAt = next
+
+	accounts := []int{sched.AccountID}
+	if sched.ToAccountID != nil {
+		accounts = append(accounts, *sched.ToAccountID)
+	}
+	var held []*models.Account
+	for _, id := range accounts {
+		acc, err := h.Accounts.GetAccount(id)
+		if err != nil {
+			scheduleError(w, err, "Account not found")
+			return
+		}
+		if !allowsCurrency(w, acc, "", sched.Amount) {
+			return
+		}
+		held = append(held, acc)
+	}
+	if len(held) == 2 && !sameCurrency(w, held[0], held[1]) {
+		return
+	}
+
+	created, err := h.Schedules.CreateSchedule(sched)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListSchedules API handler for the schedules of the account_id query
+// parameter
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.Atoi(r.URL.Query().Get("account_id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.Schedules.ListSchedules(accountID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// GetSchedule API handler
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	sched, err := h.Schedules.GetSchedule(id)
+	if err != nil {
+		scheduleError(w, err, "Schedule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(sched)
+}
+
+// PauseSchedule API handler. A paused schedule queues nothing until resumed.
+func (h *Handler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleStatus(w, r, models.SchedulePaused)
+}
+
+// CancelSchedule API handler. Cancelling is final.
+func (h *Handler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleStatus(w, r, models.ScheduleCancelled)
+}
+
+// ResumeSchedule API handler. Occurrences missed while the schedule was
+// paused are skipped; it continues with the next one from now.
+func (h *Handler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	sched, err := h.Schedules.GetSchedule(id)
+	if err != nil {
+		scheduleError(w, err, "Schedule not found")
+		return
+	}
+	switch sched.Status {
+	case models.ScheduleActive:
+		json.NewEncoder(w).Encode(sched)
+		return
+	case models.ScheduleCancelled, models.ScheduleCompleted:
+		scheduleError(w, storage.ErrScheduleClosed, "Schedule not found")
+		return
+	}
+
+	next, err := schedule.NextRun(sched, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	status := models.ScheduleActive
+	if next == nil {
+		status = models.ScheduleCompleted
+	}
+
+	sched, err = h.Schedules.SetScheduleStatus(id, status, next)
+	if err != nil {
+		scheduleError(w, err, "Schedule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(sched)
+}
+
+// setScheduleStatus moves an active or paused schedule to status
+func (h *Handler) setScheduleStatus(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	sched, err := h.Schedules.SetScheduleStatus(id, status, nil)
+	if err != nil {
+		scheduleError(w, err, "Schedule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(sched)
+}
+
+// scheduleError maps schedule storage errors to responses
+func scheduleError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, storage.ErrScheduleClosed):
+		http.Error(w, "Schedule is cancelled or completed", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// transactionPage is the response of ListTransactions
+type transactionPage struct {
+	Transactions []models.Transaction `json:"transactions"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+// ListTransactions API handler
+func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	q, err := parseTransactionQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.AccountID = id
+
+	// Ensure account exists
+	if _, err := h.Accounts.GetAccount(id); err != nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	pageSize := q.Limit
+	q.Limit++
+	transactions, err := h.Transactions.ListTransactions(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := transactionPage{Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		page.NextCursor = encodeCursor(page.Transactions[pageSize-1].ID)
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseTransactionQuery reads the filter, sort and pagination parameters
+func parseTransactionQuery(r *http.Request) (storage.TransactionQuery, error) {
+	params := r.URL.Query()
+	q := storage.TransactionQuery{
+		Type:       params.Get("type"),
+		Descending: true,
+		Limit:      defaultPageSize,
+	}
+
+	switch params.Get("sort") {
+	case "", "desc":
+	case "asc":
+		q.Descending = false
+	default:
+		return q, errors.New("sort must be asc or desc")
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		q.Limit = limit
+	}
+
+	if v := params.Get("cursor"); v != "" {
+		afterID, err := decodeCursor(v)
+		if err != nil {
+			return q, errors.New("invalid cursor")
+		}
+		q.AfterID = afterID
+	}
+
+	for name, target := range map[string]**models.Money{"min_amount": &q.MinAmount, "max_amount": &q.MaxAmount} {
+		if v := params.Get(name); v != "" {
+			amount, err := models.ParseMoney(v)
+			if err != nil {
+				return q, errors.New("invalid " + name)
+			}
+			*target = &amount
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		if v := params.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, errors.New(name + " must be an RFC 3339 timestamp")
+			}
+			*target = &t
+		}
+	}
+
+	return q, nil
+}
+
+// Cursors are opaque to clients so the keyset can change without breaking them
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"encoding/json"
+	"net/http"
+)
+
+// Transfer API handler
+func (h *Handler) Transfer(w http.ResponseWriter, r *http.Request) {
+	var tr models.Transfer
+	if err := json.NewDecoder(r.Body).Decode(&tr); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Answer retries of an already accepted request with its original outcome
+	if h.replayOperation(w, r, "transfer", "Transfer request sent to queue") {
+		return
+	}
+
+	// Prevent negative transfers
+	if tr.Amount <= 0 {
+		http.Error(w, "Transfer amount must be greater than zero", http.StatusBadRequest)
+		return
+	}
+
+	if tr.FromAccountID == tr.ToAccountID {
+		http.Error(w, "Cannot transfer to the same account", http.StatusBadRequest)
+		return
+	}
+
+	// Ensure both accounts exist
+	from, err := h.Accounts.GetAccount(tr.FromAccountID)
+	if err != nil {
+		http.Error(w, "Source account not found", http.StatusNotFound)
+		return
+	}
+	to, err := h.Accounts.GetAccount(tr.ToAccountID)
+	if err != nil {
+		http.Error(w, "Destination account not found", http.StatusNotFound)
+		return
+	}
+	if !allowsTransaction(w, from, true, "Source account") || !allowsTransaction(w, to, false, "Destination account") {
+		return
+	}
+	if !sameCurrency(w, from, to) || !allowsCurrency(w, from, "", tr.Amount) {
+		return
+	}
+
+	// Stay within the overdraft limit, leaving funds reserved by holds untouched
+	if from.AvailableBalance < tr.Amount {
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+		return
+	}
+	if !allowsProduct(w, from, "transfer_out", tr.Amount) || !allowsProduct(w, to, "transfer_in", tr.Amount) {
+		return
+	}
+
+	// Prepare the message for the worker
+	msg := messages.Transfer{FromAccountID: tr.FromAccountID, ToAccountID: tr.ToAccountID, Amount: tr.Amount}
+
+	// Record the operation and queue it for the worker
+	op, err := h.enqueue(w, r, msg)
+	if err != nil {
+		queueError(w, err, "Failed to queue transfer transaction")
+		return
+	}
+
+	// Respond to client
+	writeOperation(w, "Transfer request sent to queue", op)
+}
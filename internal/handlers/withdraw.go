@@ -1,62 +1,60 @@
 package handlers
 
 import (
+	"banking-ledger-service/internal/messages"
 	"banking-ledger-service/internal/models"
-	"banking-ledger-service/internal/queue"
-	"banking-ledger-service/internal/storage"
 	"encoding/json"
 	"net/http"
 )
 
 // Withdraw API handler
-func Withdraw(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	var tx models.Transaction
 	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
+	// Answer retries of an already accepted request with its original outcome
+	if h.replayOperation(w, r, "withdraw", "Withdrawal request sent to queue") {
+		return
+	}
+
 	// Prevent negative withdrawals
 	if tx.Amount <= 0 {
 		http.Error(w, "Withdrawal amount must be greater than zero", http.StatusBadRequest)
 		return
 	}
 
-	// Ensure account exists
-	account, err := storage.GetAccount(tx.AccountID)
+	// Ensure account exists and takes withdrawals
+	account, err := h.Accounts.GetAccount(tx.AccountID)
 	if err != nil {
 		http.Error(w, "Account not found", http.StatusNotFound)
 		return
 	}
-
-	// Prevent overdraft
-	if account.Balance < tx.Amount {
-		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+	if !allowsTransaction(w, account, true, "Account") {
 		return
 	}
 
-	// Prepare message as JSON string
-	messageData := map[string]interface{}{
-		"type":       "withdraw",
-		"account_id": tx.AccountID,
-		"amount":     tx.Amount,
+	// Stay within the overdraft limit, leaving funds reserved by holds untouched
+	if account.AvailableBalance < tx.Amount {
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+		return
 	}
-
-	// Convert to JSON string
-	messageBytes, err := json.Marshal(messageData)
-	if err != nil {
-		http.Error(w, "Failed to serialize message", http.StatusInternalServerError)
+	if !allowsProduct(w, account, "withdraw", tx.Amount) || !allowsCurrency(w, account, tx.Currency, tx.Amount) {
 		return
 	}
-	messageString := string(messageBytes)
 
-	// Send to RabbitMQ
-	err = queue.PublishMessage(messageString)
+	// Prepare the message for the worker
+	msg := messages.Withdraw{AccountID: tx.AccountID, Amount: tx.Amount, Currency: tx.Currency}
+
+	// Record the operation and queue it for the worker
+	op, err := h.enqueue(w, r, msg)
 	if err != nil {
-		http.Error(w, "Failed to queue withdrawal transaction", http.StatusInternalServerError)
+		queueError(w, err, "Failed to queue withdrawal transaction")
 		return
 	}
 
 	// Respond to client
-	json.NewEncoder(w).Encode(map[string]string{"message": "Withdrawal request sent to queue"})
+	writeOperation(w, "Withdrawal request sent to queue", op)
 }
@@ -0,0 +1,164 @@
+package interest
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"context"
+	"log"
+	"time"
+)
+
+// maxCatchUpDays bounds the days accrued for one account per run, the rest
+// follow on the next runs
+const maxCatchUpDays = 366
+
+// Engine accrues interest for every day that has ended on accounts with an
+// interest product, and posts the accruals of each compounding period once
+// it is over. Accounts with an overdraft rate accrue interest on negative
+// days the same way, charged once each month is over. Accruals are keyed by
+// account and day, so running it again or in several processes accrues and
+// posts each day once.
+type Engine struct {
+	Interest storage.InterestRepository
+	Interval time.Duration // How often to look for days to accrue
+}
+
+// Run accrues and posts interest until ctx is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.RunThrough(time.Now()); err != nil {
+			log.Println("Interest run failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunThrough accrues interest for the days before now's day and posts the
+// periods that ended before it
+func (e *Engine) RunThrough(now time.Time) error {
+	today := Date(now)
+	if err := e.runInterest(today); err != nil {
+		return err
+	}
+	return e.runOverdrafts(today)
+}
+
+// runInterest accrues and posts interest on accounts with a product
+func (e *Engine) runInterest(today time.Time) error {
+	accounts, err := e.Interest.InterestAccounts()
+	if err != nil {
+		return err
+	}
+
+	products := map[int]*models.InterestProduct{}
+	for _, acc := range accounts {
+		// Accounts whose product was removed only post what they accrued
+		compounding := models.CompoundDaily
+		if acc.ProductID != 0 {
+			product, ok := products[acc.ProductID]
+			if !ok {
+				if product, err = e.Interest.GetInterestProduct(acc.ProductID); err != nil {
+					return err
+				}
+				products[acc.ProductID] = product
+			}
+			if err := e.accrue(acc, product, today); err != nil {
+				log.Printf("Failed to accrue interest for account %d: %v", acc.AccountID, err)
+				continue
+			}
+			compounding = product.Compounding
+		}
+
+		through := PeriodStart(compounding, today).AddDate(0, 0, -1)
+		tx, err := e.Interest.PostInterest(acc.AccountID, through)
+		if err != nil {
+			log.Printf("Failed to post interest for account %d: %v", acc.AccountID, err)
+			continue
+		}
+		if tx != nil {
+			log.Printf("Posted %s interest to account %d", tx.Amount, acc.AccountID)
+		}
+	}
+	return nil
+}
+
+// runOverdrafts accrues overdraft interest and charges each month's once it
+// is over
+func (e *Engine) runOverdrafts(today time.Time) error {
+	accounts, err := e.Interest.OverdraftAccounts()
+	if err != nil {
+		return err
+	}
+
+	through := PeriodStart(models.CompoundMonthly, today).AddDate(0, 0, -1)
+	for _, acc := range accounts {
+		// Accounts whose rate was removed only charge what they accrued
+		if acc.RateBps != 0 {
+			err := e.eachDay(acc.AccountID, acc.Since, acc.AccruedThrough, today, func(day time.Time, balance models.Money) error {
+				return e.Interest.AddOverdraftAccrual(models.OverdraftAccrual{
+					AccountID: acc.AccountID,
+					Date:      day,
+					Balance:   balance,
+					Amount:    OverdraftAccrual(acc.RateBps, balance),
+				})
+			})
+			if err != nil {
+				log.Printf("Failed to accrue overdraft interest for account %d: %v", acc.AccountID, err)
+				continue
+			}
+		}
+
+		tx, err := e.Interest.ChargeOverdraftInterest(acc.AccountID, through)
+		if err != nil {
+			log.Printf("Failed to charge overdraft interest for account %d: %v", acc.AccountID, err)
+			continue
+		}
+		if tx != nil {
+			log.Printf("Charged %s overdraft interest to account %d", tx.Amount, acc.AccountID)
+		}
+	}
+	return nil
+}
+
+// accrue records the interest of each day from the account's last accrual,
+// or the day it joined its product, up to yesterday
+func (e *Engine) accrue(acc storage.InterestAccount, product *models.InterestProduct, today time.Time) error {
+	return e.eachDay(acc.AccountID, acc.Since, acc.AccruedThrough, today, func(day time.Time, balance models.Money) error {
+		return e.Interest.AddAccrual(models.InterestAccrual{
+			AccountID: acc.AccountID,
+			ProductID: product.ID,
+			Date:      day,
+			Balance:   balance,
+			Amount:    DailyAccrual(product, balance, day),
+		})
+	})
+}
+
+// eachDay calls accrue with an account's closing balance for each day from
+// the one after accruedThrough, or since, up to yesterday
+func (e *Engine) eachDay(accountID int, since time.Time, accruedThrough *time.Time, today time.Time, accrue func(day time.Time, balance models.Money) error) error {
+	day := Date(since)
+	if accruedThrough != nil && !accruedThrough.Before(day) {
+		day = Date(*accruedThrough).AddDate(0, 0, 1)
+	}
+
+	for n := 0; n < maxCatchUpDays && day.Before(today); n++ {
+		balance, err := e.Interest.EndOfDayBalance(accountID, day)
+		if err != nil {
+			return err
+		}
+		if err := accrue(day, balance); err != nil {
+			return err
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package interest accrues interest on savings accounts every day and posts
+// it to their balances once each compounding period ends. It also charges
+// interest on overdrawn balances every month.
+package interest
+
+import (
+	"banking-ledger-service/internal/models"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// Validate rejects products with an unknown convention or frequency, negative
+// rates or tiers that do not rise
+func Validate(p *models.InterestProduct) error {
+	if p.Name == "" {
+		return errors.New("product name is required")
+	}
+	if p.AnnualRateBps < 0 {
+		return errors.New("annual rate must not be negative")
+	}
+	switch p.DayCount {
+	case models.DayCountActual365, models.DayCountActual360, models.DayCountActualActual, models.DayCount30360:
+	default:
+		return errors.New("day count must be actual/365, actual/360, actual/actual or 30/360")
+	}
+	switch p.Compounding {
+	case models.CompoundDaily, models.CompoundMonthly, models.CompoundQuarterly, models.CompoundAnnually:
+	default:
+		return errors.New("compounding must be daily, monthly, quarterly or annually")
+	}
+
+	var min models.Money
+	for _, tier := range p.Tiers {
+		if tier.MinBalance <= min {
+			return errors.New("tier minimum balances must be positive and increasing")
+		}
+		if tier.AnnualRateBps < 0 {
+			return errors.New("tier rates must not be negative")
+		}
+		min = tier.MinBalance
+	}
+	return nil
+}
+
+// DailyAccrual returns the interest, in accrual units, that balance earns
+// when held at the end of day. Negative balances earn nothing.
+func DailyAccrual(p *models.InterestProduct, balance models.Money, day time.Time) int64 {
+	if balance <= 0 {
+		return 0
+	}
+
+	// Sum each band of the balance times its rate
+	yearly := new(big.Int)
+	from, rate := models.Money(0), p.AnnualRateBps
+	for _, tier := range p.Tiers {
+		if balance <= tier.MinBalance {
+			break
+		}
+		yearly.Add(yearly, bandInterest(tier.MinBalance-from, rate))
+		from, rate = tier.MinBalance, tier.AnnualRateBps
+	}
+	yearly.Add(yearly, bandInterest(balance-from, rate))
+
+	// Scale a year's interest in cent-basis-points down to one day in units
+	days, daysInYear := dayFraction(p.DayCount, day)
+	amount := yearly.Mul(yearly, big.NewInt(days*models.AccrualUnitsPerCent))
+	amount.Quo(amount, big.NewInt(10000*daysInYear))
+	return amount.Int64()
+}
+
+// OverdraftAccrual returns the interest, in accrual units, charged at an
+// annual rate of rateBps on balance when it is negative at the end of a day.
+// Overdrafts count a year as 365 days.
+func OverdraftAccrual(rateBps int, balance models.Money) int64 {
+	if balance >= 0 {
+		return 0
+	}
+	amount := bandInterest(-balance, rateBps)
+	amount.Mul(amount, big.NewInt(models.AccrualUnitsPerCent))
+	amount.Quo(amount, big.NewInt(10000*365))
+	return amount.Int64()
+}
+
+func bandInterest(amount models.Money, rateBps int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(rateBps)))
+}
+
+// dayFraction returns the share of a year that day counts for under a
+// day-count convention, as days over days in the year
+func dayFraction(dayCount string, day time.Time) (int64, int64) {
+	switch dayCount {
+	case models.DayCountActual360:
+		return 1, 360
+	case models.DayCountActualActual:
+		if isLeap(day.Year()) {
+			return 1, 366
+		}
+		return 1, 365
+	case models.DayCount30360:
+		// The 31st counts for nothing and the end of February makes up
+		// the rest of its 30 day month
+		switch {
+		case day.Day() == 31:
+			return 0, 360
+		case day.Month() == time.February && day.AddDate(0, 0, 1).Day() == 1:
+			return int64(31 - day.Day()), 360
+		}
+		return 1, 360
+	default:
+		return 1, 365
+	}
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// PeriodStart returns the first day of the compounding period containing day
+func PeriodStart(compounding string, day time.Time) time.Time {
+	y, m, d := day.Date()
+	switch compounding {
+	case models.CompoundMonthly:
+		d = 1
+	case models.CompoundQuarterly:
+		m, d = m-(m-1)%3, 1
+	case models.CompoundAnnually:
+		m, d = time.January, 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Date truncates t to the start of its UTC day
+func Date(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+// Package lifecycle defines which transactions each account status allows
+// and how administrators move accounts between statuses.
+package lifecycle
+
+import "banking-ledger-service/internal/models"
+
+// Actions an administrator can take on an account
+const (
+	Activate = "activate" // Open a pending account or wake a dormant one
+	Freeze   = "freeze"
+	Unfreeze = "unfreeze"
+	Close    = "close"
+)
+
+// moves lists the statuses each action applies to and the status it leads to
+var moves = map[string]struct {
+	from []string
+	to   string
+}{
+	Activate: {[]string{models.AccountPending, models.AccountDormant}, models.AccountActive},
+	Freeze:   {[]string{models.AccountActive, models.AccountDormant}, models.AccountFrozen},
+	Unfreeze: {[]string{models.AccountFrozen}, models.AccountActive},
+	Close:    {[]string{models.AccountPending, models.AccountActive, models.AccountFrozen, models.AccountDormant}, models.AccountClosed},
+}
+
+// Next returns the status action moves an account in status to, and false
+// when the action does not apply to that status
+func Next(action, status string) (string, bool) {
+	move, ok := moves[action]
+	if !ok {
+		return "", false
+	}
+	for _, from := range move.from {
+		if from == status {
+			return move.to, true
+		}
+	}
+	return "", false
+}
+
+// AllowsDebit reports whether money may leave an account in status
+func AllowsDebit(status string) bool {
+	return status == models.AccountActive
+}
+
+// AllowsCredit reports whether money may enter an account in status
+func AllowsCredit(status string) bool {
+	return status == models.AccountActive || status == models.AccountFrozen || status == models.AccountDormant
+}
@@ -0,0 +1,299 @@
+// Package messages defines the payloads the API queues for the worker.
+//
+// Every message is wrapped in an Envelope carrying the schema version, a
+// unique message ID, the time it was created and a correlation ID that ties
+// it back to the HTTP request. Version 1 messages, the flat JSON objects
+// published before the envelope existed, are still decoded.
+package messages
+
+import (
+	"banking-ledger-service/internal/models"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CurrentVersion is the schema version written by New
+const CurrentVersion = 2
+
+// Message types
+const (
+	TypeAccountCreation = "account_creation"
+	TypeDeposit         = "deposit"
+	TypeWithdraw        = "withdraw"
+	TypeTransfer        = "transfer"
+	TypeReversal        = "reversal"
+)
+
+// ErrInvalid is wrapped by every error for a message that can never be
+// processed, however often it is retried
+var ErrInvalid = errors.New("invalid message")
+
+// Envelope wraps a typed payload with its metadata
+type Envelope struct {
+	Version        int             `json:"version"`
+	MessageID      string          `json:"message_id"`
+	Type           string          `json:"type"`
+	Timestamp      time.Time       `json:"timestamp"`
+	CorrelationID  string          `json:"correlation_id,omitempty"`
+	OperationID    int             `json:"operation_id,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// Message is the typed payload of an envelope
+type Message interface {
+	// Type is the envelope type the payload is sent as
+	Type() string
+	// Validate rejects payloads the worker could never apply
+	Validate() error
+	// OrderingKeys identifies the accounts whose messages must stay in order
+	// with this one
+	OrderingKeys() []string
+}
+
+// AccountCreation opens an account with an initial balance. Without a
+// customer the account gets a new customer named after it, and without a
+// product code it opens on the default product.
+type AccountCreation struct {
+	CustomerID  *int         `json:"customer_id,omitempty"`
+	Name        string       `json:"name"`
+	ProductCode string       `json:"product_code,omitempty"`
+	Currency    string       `json:"currency,omitempty"`
+	Balance     models.Money `json:"balance"`
+}
+
+func (AccountCreation) Type() string { return TypeAccountCreation }
+
+func (m AccountCreation) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("%w: missing account name", ErrInvalid)
+	}
+	if m.Balance < 0 {
+		return fmt.Errorf("%w: balance must not be negative", ErrInvalid)
+	}
+	return nil
+}
+
+// Account creations have no ID yet and are ordered by name
+func (m AccountCreation) OrderingKeys() []string { return []string{m.Name} }
+
+// Deposit credits an account. A non-empty currency must match the
+// account's.
+type Deposit struct {
+	AccountID int          `json:"account_id"`
+	Amount    models.Money `json:"amount"`
+	Currency  string       `json:"currency,omitempty"`
+}
+
+func (Deposit) Type() string { return TypeDeposit }
+
+func (m Deposit) Validate() error {
+	return validateAccountAmount(m.AccountID, m.Amount)
+}
+
+func (m Deposit) OrderingKeys() []string { return []string{strconv.Itoa(m.AccountID)} }
+
+// Withdraw debits an account. A non-empty currency must match the
+// account's.
+type Withdraw struct {
+	AccountID int          `json:"account_id"`
+	Amount    models.Money `json:"amount"`
+	Currency  string       `json:"currency,omitempty"`
+}
+
+func (Withdraw) Type() string { return TypeWithdraw }
+
+func (m Withdraw) Validate() error {
+	return validateAccountAmount(m.AccountID, m.Amount)
+}
+
+func (m Withdraw) OrderingKeys() []string { return []string{strconv.Itoa(m.AccountID)} }
+
+// Transfer moves funds between two accounts
+type Transfer struct {
+	FromAccountID int          `json:"from_account_id"`
+	ToAccountID   int          `json:"to_account_id"`
+	Amount        models.Money `json:"amount"`
+}
+
+func (Transfer) Type() string { return TypeTransfer }
+
+func (m Transfer) Validate() error {
+	if err := validateAccountAmount(m.FromAccountID, m.Amount); err != nil {
+		return err
+	}
+	if m.ToAccountID <= 0 {
+		return fmt.Errorf("%w: missing destination account", ErrInvalid)
+	}
+	if m.FromAccountID == m.ToAccountID {
+		return fmt.Errorf("%w: cannot transfer to the same account", ErrInvalid)
+	}
+	return nil
+}
+
+// Transfers are ordered with both the account they debit and the one they
+// credit
+func (m Transfer) OrderingKeys() []string {
+	return []string{strconv.Itoa(m.FromAccountID), strconv.Itoa(m.ToAccountID)}
+}
+
+// Reversal undoes a posted deposit or withdrawal
+type Reversal struct {
+	TransactionID int    `json:"transaction_id"`
+	AccountID     int    `json:"account_id"` // Account of the reversed transaction
+	Reason        string `json:"reason"`
+	Actor         string `json:"actor"`
+}
+
+func (Reversal) Type() string { return TypeReversal }
+
+func (m Reversal) Validate() error {
+	if m.TransactionID <= 0 {
+		return fmt.Errorf("%w: missing transaction", ErrInvalid)
+	}
+	if m.Reason == "" || m.Actor == "" {
+		return fmt.Errorf("%w: reversals need a reason and an actor", ErrInvalid)
+	}
+	return nil
+}
+
+func (m Reversal) OrderingKeys() []string { return []string{strconv.Itoa(m.AccountID)} }
+
+func validateAccountAmount(accountID int, amount models.Money) error {
+	if accountID <= 0 {
+		return fmt.Errorf("%w: missing account", ErrInvalid)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be greater than zero", ErrInvalid)
+	}
+	return nil
+}
+
+// New validates msg and wraps it in an envelope of the current version. A
+// new message ID is used as the correlation ID when none is given.
+func New(msg Message, correlationID string) (*Envelope, error) {
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	id := NewID()
+	if correlationID == "" {
+		correlationID = id
+	}
+	return &Envelope{
+		Version:       CurrentVersion,
+		MessageID:     id,
+		Type:          msg.Type(),
+		Timestamp:     time.Now().UTC(),
+		CorrelationID: correlationID,
+		Payload:       payload,
+	}, nil
+}
+
+// Encode serializes the envelope for the queue
+func (e *Envelope) Encode() (string, error) {
+	body, err := json.Marshal(e)
+	return string(body), err
+}
+
+// Decode parses and validates a queued message. The envelope is returned
+// whenever it could be read, even if its payload is invalid, so the caller
+// can still settle the operation it belongs to.
+func Decode(body []byte) (*Envelope, Message, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var msg Message
+	var err error
+	switch {
+	case env.Version <= 1:
+		msg, err = decodeV1(&env, body)
+	case env.Version == CurrentVersion:
+		msg, err = decodePayload(env.Type, env.Payload)
+	default:
+		return &env, nil, fmt.Errorf("%w: unsupported schema version %d", ErrInvalid, env.Version)
+	}
+	if err != nil {
+		return &env, nil, err
+	}
+
+	if err := msg.Validate(); err != nil {
+		return &env, nil, err
+	}
+	return &env, msg, nil
+}
+
+// decodePayload parses a version 2 payload of the given type
+func decodePayload(msgType string, payload json.RawMessage) (Message, error) {
+	var msg Message
+	switch msgType {
+	case TypeAccountCreation:
+		msg = &AccountCreation{}
+	case TypeDeposit:
+		msg = &Deposit{}
+	case TypeWithdraw:
+		msg = &Withdraw{}
+	case TypeTransfer:
+		msg = &Transfer{}
+	case TypeReversal:
+		msg = &Reversal{}
+	case "":
+		return nil, fmt.Errorf("%w: missing transaction type", ErrInvalid)
+	default:
+		return nil, fmt.Errorf("%w: unknown transaction type %q", ErrInvalid, msgType)
+	}
+
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("%w: missing payload", ErrInvalid)
+	}
+	if err := json.Unmarshal(payload, msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return deref(msg), nil
+}
+
+// decodeV1 reads a flat version 1 message, in which the payload fields sit
+// next to type, operation_id and idempotency_key, and fills in the envelope
+func decodeV1(env *Envelope, body []byte) (Message, error) {
+	env.Version = 1
+	env.Payload = body
+	return decodePayload(env.Type, body)
+}
+
+// deref returns the value a decoded payload pointer refers to, so callers
+// can switch on the plain message types
+func deref(msg Message) Message {
+	switch m := msg.(type) {
+	case *AccountCreation:
+		return *m
+	case *Deposit:
+		return *m
+	case *Withdraw:
+		return *m
+	case *Transfer:
+		return *m
+	case *Reversal:
+		return *m
+	}
+	return msg
+}
+
+// NewID returns a random version 4 UUID
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -4,16 +4,244 @@ import "time"
 
 // Account represents a bank account
 type Account struct {
-	ID      int     `json:"id"`
-	Name    string  `json:"name"`
-	Balance float64 `json:"balance"`
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	CustomerID        *int   `json:"customer_id,omitempty"`  // Customer holding the account
+	ProductCode       string `json:"product_code,omitempty"` // Catalog product whose rules the account follows
+	Currency          string `json:"currency,omitempty"`     // ISO 4217 code every amount on the account is in
+	Status            string `json:"status,omitempty"`
+	Balance           Money  `json:"balance"`
+	AvailableBalance  Money  `json:"available_balance"`      // Balance less active holds plus the overdraft limit
+	AccountType       string `json:"account_type,omitempty"` // Selects the fee rules that apply
+	InterestProductID *int   `json:"interest_product_id,omitempty"`
+	OverdraftLimit    Money  `json:"overdraft_limit,omitempty"`    // How far below zero debits may take the balance
+	OverdraftRateBps  int    `json:"overdraft_rate_bps,omitempty"` // Annual interest charged on a negative balance
+}
+
+// Customer is a person who holds one or more accounts
+type Customer struct {
+	ID          int       `json:"id"`
+	LegalName   string    `json:"legal_name"`
+	Email       string    `json:"email,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	DateOfBirth string    `json:"date_of_birth,omitempty"` // YYYY-MM-DD
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Product is an entry of the account catalog. Accounts on a product open in
+// its currency unless another is requested, take only its transaction types,
+// and debits must leave at least MinBalance available. Withdrawals and
+// transfers out are capped at MaxWithdrawal each and MonthlyWithdrawals per
+// calendar month; zero means no limit. Amounts are in the account's
+// currency.
+type Product struct {
+	Code               string   `json:"code"`
+	Name               string   `json:"name"`
+	Currency           string   `json:"currency"`
+	MinBalance         Money    `json:"min_balance"`
+	MaxWithdrawal      Money    `json:"max_withdrawal,omitempty"`
+	MonthlyWithdrawals int      `json:"monthly_withdrawals,omitempty"`
+	TransactionTypes   []string `json:"transaction_types"`
+}
+
+// DefaultAccountType is the type of new accounts
+const DefaultAccountType = "standard"
+
+// Account statuses. Active accounts take any transaction, frozen and
+// dormant ones only credits, and pending and closed ones none.
+const (
+	AccountPending = "pending"
+	AccountActive  = "active"
+	AccountFrozen  = "frozen"
+	AccountDormant = "dormant"
+	AccountClosed  = "closed"
+)
+
+// AccountEvent records a change of an account's status
+type AccountEvent struct {
+	ID         int       `json:"id"`
+	AccountID  int       `json:"account_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // Transaction represents a bank transaction
 type Transaction struct {
+	ID           int       `json:"id"`
+	AccountID    int       `json:"account_id"`
+	Amount       Money     `json:"amount"`
+	Currency     string    `json:"currency,omitempty"`      // The account's currency
+	Type         string    `json:"type"`                    // "account_creation", "deposit", "withdraw", "transfer_in", "transfer_out", "capture", "reversal", "interest", "fee", "overdraft_interest", "payout"
+	BalanceAfter *Money    `json:"balance_after,omitempty"` // Account balance once this transaction was applied
+	ReversalOf   *int      `json:"reversal_of,omitempty"`   // Transaction undone by a reversal
+	ReversedBy   *int      `json:"reversed_by,omitempty"`   // Reversal that undid this transaction
+	FeeFor       *int      `json:"fee_for,omitempty"`       // Transaction a fee was charged on
+	Reason       string    `json:"reason,omitempty"`
+	Actor        string    `json:"actor,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Transfer represents a movement of funds between two accounts
+type Transfer struct {
+	FromAccountID int   `json:"from_account_id"`
+	ToAccountID   int   `json:"to_account_id"`
+	Amount        Money `json:"amount"`
+}
+
+// Operation statuses reported by GET /operations/{id}
+const (
+	OperationQueued     = "queued"
+	OperationProcessing = "processing"
+	OperationSucceeded  = "succeeded"
+	OperationFailed     = "failed"
+)
+
+// Operation tracks the outcome of a request handed to the worker
+type Operation struct {
 	ID        int       `json:"id"`
-	AccountID int       `json:"account_id"`
-	Amount    float64   `json:"amount"`
-	Type      string    `json:"type"` // "account_creation", "deposit", "withdraw"
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	AccountID *int      `json:"account_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Hold statuses. An active hold past its expiry is reported as expired.
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// Hold reserves funds on an account without moving them
+type Hold struct {
+	ID             int       `json:"id"`
+	AccountID      int       `json:"account_id"`
+	Amount         Money     `json:"amount"`
+	CapturedAmount Money     `json:"captured_amount"`
+	Reference      string    `json:"reference,omitempty"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Schedule statuses. A schedule is completed once it has no occurrences left.
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCancelled = "cancelled"
+	ScheduleCompleted = "completed"
+)
+
+// Schedule repeats a deposit, withdrawal or transfer on a cron or interval
+// rule between StartAt and the optional EndAt
+type Schedule struct {
+	ID              int        `json:"id"`
+	Type            string     `json:"type"` // "deposit", "withdraw", "transfer"
+	AccountID       int        `json:"account_id"`
+	ToAccountID     *int       `json:"to_account_id,omitempty"` // Destination of transfers
+	Amount          Money      `json:"amount"`
+	Cron            string     `json:"cron,omitempty"`
+	IntervalSeconds int64      `json:"interval_seconds,omitempty"`
+	StartAt         time.Time  `json:"start_at"`
+	EndAt           *time.Time `json:"end_at,omitempty"`
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"` // Unset once completed
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Day-count conventions turning an annual rate into a daily one
+const (
+	DayCountActual365    = "actual/365"
+	DayCountActual360    = "actual/360"
+	DayCountActualActual = "actual/actual" // 365 or 366 days by calendar year
+	DayCount30360        = "30/360"        // Every month counts as 30 days
+)
+
+// Compounding frequencies, how often accrued interest is posted to the
+// balance it then earns interest on
+const (
+	CompoundDaily     = "daily"
+	CompoundMonthly   = "monthly"
+	CompoundQuarterly = "quarterly"
+	CompoundAnnually  = "annually"
+)
+
+// AccrualUnitsPerCent is the precision interest accrues in before it is
+// rounded to cents and posted
+const AccrualUnitsPerCent = 1_000_000
+
+// InterestProduct sets how savings accounts earn interest. The annual rate
+// applies to the balance below the first tier; each tier's rate applies to
+// the part of the balance from its minimum up to the next tier.
+type InterestProduct struct {
+	ID            int            `json:"id"`
+	Name          string         `json:"name"`
+	AnnualRateBps int            `json:"annual_rate_bps"` // Basis points, 1/100 of a percent
+	DayCount      string         `json:"day_count"`
+	Compounding   string         `json:"compounding"`
+	Tiers         []InterestTier `json:"tiers"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// InterestTier is the rate for balances from MinBalance upwards
+type InterestTier struct {
+	MinBalance    Money `json:"min_balance"`
+	AnnualRateBps int   `json:"annual_rate_bps"`
+}
+
+// InterestAccrual is the interest an account earned on one day's closing
+// balance, until it is posted by an interest transaction
+type InterestAccrual struct {
+	AccountID     int       `json:"account_id"`
+	ProductID     int       `json:"product_id"`
+	Date          time.Time `json:"date"`
+	Balance       Money     `json:"balance"`                  // Balance at the end of Date
+	Amount        int64     `json:"amount"`                   // In AccrualUnitsPerCent of a cent
+	TransactionID *int      `json:"transaction_id,omitempty"` // Interest transaction that posted it
+}
+
+// OverdraftAccrual is the interest charged on one day's negative closing
+// balance, until it is posted by an overdraft_interest transaction
+type OverdraftAccrual struct {
+	AccountID     int       `json:"account_id"`
+	Date          time.Time `json:"date"`
+	Balance       Money     `json:"balance"`                  // Balance at the end of Date
+	Amount        int64     `json:"amount"`                   // In AccrualUnitsPerCent of a cent
+	TransactionID *int      `json:"transaction_id,omitempty"` // Transaction that charged it
+}
+
+// FeeRule charges a fee on deposits or withdrawals. A transaction is charged
+// when it is at least MinAmount and the account already made FreePerMonth
+// transactions of the same type this calendar month. The fee is FlatFee plus
+// RateBps of the amount, capped at MaxFee.
+type FeeRule struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	TransactionType string    `json:"transaction_type"`       // "deposit" or "withdraw"
+	AccountType     string    `json:"account_type,omitempty"` // Empty applies to every account type
+	MinAmount       Money     `json:"min_amount"`
+	FreePerMonth    int       `json:"free_per_month"`
+	FlatFee         Money     `json:"flat_fee"`
+	RateBps         int       `json:"rate_bps"` // Basis points of the amount
+	MaxFee          *Money    `json:"max_fee,omitempty"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// FeeCharge is the fee one rule charges on a transaction
+type FeeCharge struct {
+	RuleID int    `json:"rule_id"`
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
 }
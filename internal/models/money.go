@@ -0,0 +1,203 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount held in minor currency units (cents). It is
+// written to JSON as a decimal number with two places, or with the places of
+// its currency where one is known, and stored in Postgres as a BIGINT of
+// minor units.
+type Money int64
+
+// minorUnits is the number of decimal places carried by Money
+const minorUnits = 2
+
+// currencyPlaces is the number of decimal places of each ISO 4217 currency
+// accounts may hold. None may carry more than minorUnits, so currencies with
+// three, such as KWD, are left out.
+var currencyPlaces = map[string]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"SEK": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// Places returns the number of decimal places of currency code and whether
+// accounts may hold it
+func Places(code string) (int, bool) {
+	places, ok := currencyPlaces[code]
+	return places, ok
+}
+
+var errInvalidMoney = errors.New("invalid amount")
+
+// ParseMoney parses a decimal string such as "12", "-0.5" or "1049.99" into
+// Money. Exponents, thousands separators and more than two decimal places
+// are rejected rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	digits := s
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && frac == "") {
+		return 0, fmt.Errorf("%w %q", errInvalidMoney, s)
+	}
+	if len(frac) > minorUnits {
+		return 0, fmt.Errorf("%w %q: at most %d decimal places are allowed", errInvalidMoney, s, minorUnits)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w %q", errInvalidMoney, s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/100 {
+		return 0, fmt.Errorf("%w %q: out of range", errInvalidMoney, s)
+	}
+
+	frac += strings.Repeat("0", minorUnits-len(frac))
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal with two places, e.g. "-12.50"
+func (m Money) String() string {
+	return formatPlaces(int64(m), minorUnits)
+}
+
+// Format formats the amount with the decimal places of currency code, e.g.
+// "1500" for yen. Unknown currencies, and amounts finer than the currency
+// allows, keep two places so nothing is lost.
+func (m Money) Format(code string) string {
+	places, ok := Places(code)
+	scale := int64(math.Pow10(minorUnits - places))
+	if !ok || int64(m)%scale != 0 {
+		return m.String()
+	}
+	return formatPlaces(int64(m)/scale, places)
+}
+
+// formatPlaces formats v, a count of 10^-places units, as a decimal
+func formatPlaces(v int64, places int) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+	}
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-(v + 1)) + 1
+	}
+	if places == 0 {
+		return fmt.Sprintf("%s%d", sign, u)
+	}
+	scale := uint64(math.Pow10(places))
+	return fmt.Sprintf("%s%d.%0*d", sign, u/scale, places, u%scale)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a decimal string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidMoney, data)
+		}
+		data = []byte(s)
+	}
+
+	parsed, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as an integer number of minor units
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// amountIn is an amount written to JSON with the decimal places of its
+// currency
+type amountIn struct {
+	amount   Money
+	currency string
+}
+
+func (a amountIn) MarshalJSON() ([]byte, error) {
+	return []byte(a.amount.Format(a.currency)), nil
+}
+
+// MarshalJSON writes the account's amounts with the decimal places of its
+// currency, so a yen balance reads 1500 rather than 1500.00
+func (a Account) MarshalJSON() ([]byte, error) {
+	type plain Account
+	out := struct {
+		plain
+		Balance          amountIn  `json:"balance"`
+		AvailableBalance amountIn  `json:"available_balance"`
+		OverdraftLimit   *amountIn `json:"overdraft_limit,omitempty"`
+	}{
+		plain:            plain(a),
+		Balance:          amountIn{a.Balance, a.Currency},
+		AvailableBalance: amountIn{a.AvailableBalance, a.Currency},
+	}
+	if a.OverdraftLimit != 0 {
+		out.OverdraftLimit = &amountIn{a.OverdraftLimit, a.Currency}
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON writes the transaction's amounts with the decimal places of
+// its currency
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type plain Transaction
+	out := struct {
+		plain
+		Amount       amountIn  `json:"amount"`
+		BalanceAfter *amountIn `json:"balance_after,omitempty"`
+	}{
+		plain:  plain(t),
+		Amount: amountIn{t.Amount, t.Currency},
+	}
+	if t.BalanceAfter != nil {
+		out.BalanceAfter = &amountIn{*t.BalanceAfter, t.Currency}
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"banking-ledger-service/internal/storage"
+	"context"
+	"log"
+	"time"
+)
+
+// Relay moves messages from the Postgres outbox table to the queue. Handlers
+// write a message in the same transaction as the operation it belongs to and
+// the relay publishes it durably, so every accepted request
+// reaches the queue at least once even if it was briefly unavailable when
+// it was made.
+type Relay struct {
+	Interval     time.Duration // How often to poll for unsent messages
+	BatchSize    int           // Maximum messages claimed at a time
+	ClaimTimeout time.Duration // How long a claimed batch is hidden from other relays
+
+	// Publish durably hands one message to the queue, returning once the
+	// queue has accepted it
+	Publish func(message string) error
+}
+
+// Run relays messages until ctx is cancelled
+func (r Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches straight away, then wait for the next tick
+		for {
+			sent, err := storage.RelayOutbox(r.BatchSize, r.ClaimTimeout, r.Publish)
+			if err != nil {
+				log.Println("Outbox relay failed:", err)
+			}
+			if err != nil || sent < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package products defines the account catalog and the rules each product
+// sets on the transactions of its accounts.
+package products
+
+import (
+	"banking-ledger-service/internal/models"
+	"fmt"
+	"sort"
+)
+
+// Product codes
+const (
+	Checking = "checking"
+	Savings  = "savings"
+	Business = "business"
+	Escrow   = "escrow"
+)
+
+// Default is the product of accounts opened without one
+const Default = Checking
+
+// catalog lists every product by code
+var catalog = map[string]models.Product{
+	Checking: {
+		Code: Checking, Name: "Checking", Currency: "USD",
+		TransactionTypes: []string{"deposit", "withdraw", "transfer_in", "transfer_out", "capture"},
+	},
+	Savings: {
+		Code: Savings, Name: "Savings", Currency: "USD",
+		MinBalance: 10000, MonthlyWithdrawals: 6,
+		TransactionTypes: []string{"deposit", "withdraw", "transfer_in", "transfer_out"},
+	},
+	Business: {
+		Code: Business, Name: "Business checking", Currency: "USD",
+		MinBalance: 100000, MaxWithdrawal: 5000000,
+		TransactionTypes: []string{"deposit", "withdraw", "transfer_in", "transfer_out", "capture"},
+	},
+	// Escrowed funds only leave by transfer to the party they are held for
+	Escrow: {
+		Code: Escrow, Name: "Escrow", Currency: "USD",
+		TransactionTypes: []string{"deposit", "transfer_in", "transfer_out"},
+	},
+}
+
+// Get returns the product with code, and false when there is none
+func Get(code string) (models.Product, bool) {
+	p, ok := catalog[code]
+	return p, ok
+}
+
+// List returns the catalog ordered by code
+func List() []models.Product {
+	list := make([]models.Product, 0, len(catalog))
+	for _, p := range catalog {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Check reports why p does not allow a transaction of txType and amount on
+// an account with available funds after holds, not counting any overdraft,
+// that already made withdrawals and transfers out this calendar month.
+// Bank-side transactions such as interest, fees and reversals are not
+// checked.
+func Check(p models.Product, txType string, amount, available models.Money, withdrawals int) error {
+	if !allows(p, txType) {
+		return fmt.Errorf("%s accounts do not allow %s", p.Code, txType)
+	}
+	if txType == "deposit" || txType == "transfer_in" {
+		return nil
+	}
+	if p.MinBalance > 0 && available-amount < p.MinBalance {
+		return fmt.Errorf("%s accounts must keep a balance of %s", p.Code, p.MinBalance)
+	}
+	if txType == "capture" {
+		return nil
+	}
+	if p.MaxWithdrawal > 0 && amount > p.MaxWithdrawal {
+		return fmt.Errorf("%s accounts allow withdrawals of at most %s", p.Code, p.MaxWithdrawal)
+	}
+	if p.MonthlyWithdrawals > 0 && withdrawals >= p.MonthlyWithdrawals {
+		return fmt.Errorf("%s accounts allow %d withdrawals a month", p.Code, p.MonthlyWithdrawals)
+	}
+	return nil
+}
+
+// CheckOpening reports why p does not allow an account to open with balance
+func CheckOpening(p models.Product, balance models.Money) error {
+	if balance < p.MinBalance {
+		return fmt.Errorf("%s accounts must open with at least %s", p.Code, p.MinBalance)
+	}
+	return nil
+}
+
+func allows(p models.Product, txType string) bool {
+	for _, t := range p.TransactionTypes {
+		if t == txType {
+			return true
+		}
+	}
+	return false
+}
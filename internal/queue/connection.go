@@ -0,0 +1,208 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// channelPoolSize is the number of idle publishing channels kept open
+	channelPoolSize = 16
+
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+var errNotConnected = errors.New("not connected to RabbitMQ")
+
+// connection keeps a RabbitMQ connection open, redialling with backoff and
+// redeclaring the topology whenever the broker closes it. Publishers borrow
+// confirm-mode channels from a pool so concurrent handler goroutines never
+// share a channel.
+type connection struct {
+	url string
+
+	mu   sync.Mutex
+	conn *amqp091.Connection
+
+	pool chan *amqp091.Channel
+}
+
+// dial connects to url and declares the topology
+func dial(url string) (*connection, error) {
+	c := &connection{url: url, pool: make(chan *amqp091.Channel, channelPoolSize)}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *connection) connect() error {
+	conn, err := amqp091.Dial(c.url)
+	if err != nil {
+		return err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	err = declareTopology(ch)
+	ch.Close()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	closed := conn.NotifyClose(make(chan *amqp091.Error, 1))
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	go c.reconnectOnClose(closed)
+	return nil
+}
+
+// reconnectOnClose waits for the connection to close and dials again until
+// it succeeds. A graceful close by this process is not reconnected.
+func (c *connection) reconnectOnClose(closed <-chan *amqp091.Error) {
+	reason, ok := <-closed
+	if !ok {
+		return
+	}
+	log.Println("RabbitMQ connection lost, reconnecting:", reason)
+
+	delay := reconnectMinDelay
+	for {
+		time.Sleep(delay)
+		err := c.connect()
+		if err == nil {
+			log.Println("Reconnected to RabbitMQ")
+			return
+		}
+		log.Printf("Failed to reconnect to RabbitMQ, retrying in %s: %v", delay, err)
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+}
+
+// current returns the open connection, if any
+func (c *connection) current() (*amqp091.Connection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, errNotConnected
+	}
+	return c.conn, nil
+}
+
+// channel borrows a confirm-mode channel, opening one when the pool is empty.
+// Channels left over from a closed connection are discarded.
+func (c *connection) channel() (*amqp091.Channel, error) {
+	for {
+		select {
+		case ch := <-c.pool:
+			if ch.IsClosed() {
+				continue
+			}
+			return ch, nil
+		default:
+		}
+		break
+	}
+
+	conn, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return ch, nil
+}
+
+// release returns a borrowed channel to the pool
+func (c *connection) release(ch *amqp091.Channel) {
+	if ch.IsClosed() {
+		return
+	}
+	select {
+	case c.pool <- ch:
+	default:
+		ch.Close()
+	}
+}
+
+// publish sends msg as a persistent message and waits until the broker
+// confirms it has taken responsibility for it
+func (c *connection) publish(exchange, routingKey string, msg amqp091.Publishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), confirmTimeout)
+	defer cancel()
+
+	ch, err := c.channel()
+	if err != nil {
+		return err
+	}
+	defer c.release(ch)
+
+	msg.DeliveryMode = amqp091.Persistent
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// The confirm may still arrive; don't hand the channel to another publisher
+		ch.Close()
+		return err
+	}
+	if !acked {
+		return errors.New("message was not confirmed by the broker")
+	}
+	return nil
+}
+
+// consume opens a dedicated channel and starts consuming the work queue with
+// at most prefetch unacknowledged deliveries
+func (c *connection) consume(prefetch int) (<-chan amqp091.Delivery, error) {
+	conn, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ch.Qos(prefetch, 0, false)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	messages, err := ch.Consume(
+		queueName,
+		"",
+		false, // Manual acknowledgment
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return messages, nil
+}
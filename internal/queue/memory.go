@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// errQueueFull is returned when the in-memory queue cannot take more messages
+var errQueueFull = errors.New("queue is full")
+
+// MemoryQueue is an in-process stand-in for RabbitMQ. Retries follow the same
+// backoff and retry limit, and dead-lettered messages are kept in memory.
+// Messages are lost when the process exits.
+type MemoryQueue struct {
+	messages chan memoryDelivery
+
+	mu   sync.Mutex
+	dead []DeadMessage
+}
+
+// DeadMessage is a message the in-memory queue gave up on
+type DeadMessage struct {
+	Body   []byte
+	Reason string
+	At     time.Time
+}
+
+// NewMemoryQueue creates a queue holding up to capacity undelivered messages
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{messages: make(chan memoryDelivery, capacity)}
+}
+
+// PublishMessage queues a message for the consumer
+func (q *MemoryQueue) PublishMessage(message string) error {
+	return q.push(memoryDelivery{queue: q, body: []byte(message)})
+}
+
+func (q *MemoryQueue) push(d memoryDelivery) error {
+	select {
+	case q.messages <- d:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// ConsumeMessages returns the queue's deliveries. The in-memory queue has a
+// single consumer, so prefetch is ignored.
+func (q *MemoryQueue) ConsumeMessages(prefetch int) (<-chan Delivery, error) {
+	deliveries := make(chan Delivery)
+	go func() {
+		for d := range q.messages {
+			deliveries <- d
+		}
+	}()
+	return deliveries, nil
+}
+
+// DeadLetters returns the messages that were dead-lettered so far
+func (q *MemoryQueue) DeadLetters() []DeadMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]DeadMessage(nil), q.dead...)
+}
+
+// memoryDelivery is a message received from a MemoryQueue
+type memoryDelivery struct {
+	queue   *MemoryQueue
+	body    []byte
+	retries int
+}
+
+func (d memoryDelivery) Body() []byte    { return d.body }
+func (d memoryDelivery) RetryCount() int { return d.retries }
+func (d memoryDelivery) Ack() error      { return nil }
+
+// Retry requeues the message after the same backoff RabbitMQ would apply,
+// dead-lettering it once MaxRetries is used up
+func (d memoryDelivery) Retry(reason string) error {
+	attempt := d.retries + 1
+	if attempt > MaxRetries {
+		return d.DeadLetter(reason)
+	}
+
+	log.Printf("Retrying message in %s (attempt %d of %d): %s", retryDelay(attempt), attempt, MaxRetries, reason)
+	time.AfterFunc(retryDelay(attempt), func() {
+		if err := d.queue.push(memoryDelivery{queue: d.queue, body: d.body, retries: attempt}); err != nil {
+			d.DeadLetter(reason + ": " + err.Error())
+		}
+	})
+	return nil
+}
+
+func (d memoryDelivery) DeadLetter(reason string) error {
+	log.Println("Dead-lettering message:", reason)
+	d.queue.mu.Lock()
+	defer d.queue.mu.Unlock()
+	d.queue.dead = append(d.queue.dead, DeadMessage{Body: d.body, Reason: reason, At: time.Now()})
+	return nil
+}
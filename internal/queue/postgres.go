@@ -0,0 +1,287 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// jobsChannel is the LISTEN/NOTIFY channel signalled when jobs are inserted
+const jobsChannel = "jobs"
+
+// PostgresQueue is a job queue kept in the jobs table, for deployments that
+// would rather not run RabbitMQ. Consumers claim ready jobs with
+// FOR UPDATE SKIP LOCKED and hide them for VisibilityTimeout, which is
+// extended for as long as the consumer holds them unsettled; a job whose
+// consumer stopped is delivered again once it runs out. Retries use the same
+// backoff and limit as RabbitMQ and exhausted jobs are kept as dead.
+type PostgresQueue struct {
+	pool *pgxpool.Pool
+
+	VisibilityTimeout time.Duration // How long a claimed job stays hidden
+	PollInterval      time.Duration // Fallback poll for retries and expired claims
+
+	mu       sync.Mutex
+	inflight map[int64]bool // Claimed jobs not yet settled
+	settled  chan struct{}  // Signalled when a claimed job is settled
+}
+
+// NewPostgresQueue creates a queue on the jobs table of pool
+func NewPostgresQueue(pool *pgxpool.Pool) *PostgresQueue {
+	return &PostgresQueue{
+		pool:              pool,
+		VisibilityTimeout: 30 * time.Second,
+		PollInterval:      5 * time.Second,
+		inflight:          map[int64]bool{},
+		settled:           make(chan struct{}, 1),
+	}
+}
+
+// PublishMessage queues a message. The job is durable once this returns, so
+// it also serves as a confirmed publish.
+func (q *PostgresQueue) PublishMessage(message string) error {
+	_, err := q.pool.Exec(context.Background(), "INSERT INTO jobs (payload) VALUES ($1)", message)
+	if err != nil {
+		log.Println("Failed to publish message:", err)
+		return err
+	}
+
+	log.Println("Message published to queue:", message)
+	return nil
+}
+
+// ConsumeMessages delivers jobs in the order they were queued. Like the
+// RabbitMQ prefetch, at most prefetch jobs are claimed and unsettled at a
+// time, and their claims are kept alive until they are settled. Consumers
+// are woken by NOTIFY when jobs are inserted and poll every PollInterval for
+// retries that have become due.
+func (q *PostgresQueue) ConsumeMessages(prefetch int) (<-chan Delivery, error) {
+	wakeups, err := q.listen()
+	if err != nil {
+		log.Println("Failed to listen for jobs:", err)
+		return nil, err
+	}
+	go q.keepClaims()
+
+	deliveries := make(chan Delivery)
+	go func() {
+		for {
+			free := prefetch - q.inflightCount()
+			if free <= 0 {
+				q.wait(q.settled)
+				continue
+			}
+
+			jobs, err := q.claim(free)
+			if err != nil {
+				log.Println("Failed to claim jobs:", err)
+			}
+			for _, job := range jobs {
+				deliveries <- job
+			}
+			if err != nil || len(jobs) < free {
+				q.wait(wakeups)
+			}
+		}
+	}()
+	return deliveries, nil
+}
+
+// keepClaims extends the visibility timeout of unsettled jobs well before
+// it runs out, so jobs waiting behind others in the consumer are never
+// claimed a second time
+func (q *PostgresQueue) keepClaims() {
+	for range time.Tick(q.VisibilityTimeout / 3) {
+		ids := q.inflightIDs()
+		if len(ids) == 0 {
+			continue
+		}
+		_, err := q.pool.Exec(context.Background(),
+			`UPDATE jobs SET locked_until = CURRENT_TIMESTAMP + $1 * INTERVAL '1 millisecond'
+			WHERE id = ANY($2) AND locked_until IS NOT NULL AND dead_at IS NULL`,
+			q.VisibilityTimeout.Milliseconds(), ids)
+		if err != nil {
+			log.Println("Failed to extend job claims:", err)
+		}
+	}
+}
+
+func (q *PostgresQueue) inflightCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.inflight)
+}
+
+func (q *PostgresQueue) inflightIDs() []int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := make([]int64, 0, len(q.inflight))
+	for id := range q.inflight {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// release forgets a settled job and wakes a consumer waiting for room
+func (q *PostgresQueue) release(id int64) {
+	q.mu.Lock()
+	delete(q.inflight, id)
+	q.mu.Unlock()
+
+	select {
+	case q.settled <- struct{}{}:
+	default:
+	}
+}
+
+// listen holds a connection on LISTEN and signals the returned channel on
+// every notification, reconnecting if the connection is lost
+func (q *PostgresQueue) listen() (<-chan struct{}, error) {
+	conn, err := q.pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(context.Background(), "LISTEN "+jobsChannel); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	wakeups := make(chan struct{}, 1)
+	go func() {
+		for {
+			_, err := conn.Conn().WaitForNotification(context.Background())
+			if err == nil {
+				select {
+				case wakeups <- struct{}{}:
+				default:
+				}
+				continue
+			}
+
+			log.Println("Lost job notifications, reconnecting:", err)
+			conn.Hijack().Close(context.Background())
+			for {
+				time.Sleep(q.PollInterval)
+				conn, err = q.pool.Acquire(context.Background())
+				if err != nil {
+					continue
+				}
+				if _, err = conn.Exec(context.Background(), "LISTEN "+jobsChannel); err == nil {
+					break
+				}
+				conn.Release()
+			}
+		}
+	}()
+	return wakeups, nil
+}
+
+// wait blocks until wakeups is signalled or the poll interval elapses
+func (q *PostgresQueue) wait(wakeups <-chan struct{}) {
+	select {
+	case <-wakeups:
+	case <-time.After(q.PollInterval):
+	}
+}
+
+// claim hides up to limit ready jobs for the visibility timeout, holds them
+// as in flight and returns them in queue order
+func (q *PostgresQueue) claim(limit int) ([]*postgresDelivery, error) {
+	rows, err := q.pool.Query(context.Background(), `
+		UPDATE jobs SET locked_until = CURRENT_TIMESTAMP + $1 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE dead_at IS NULL AND available_at <= CURRENT_TIMESTAMP
+				AND (locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, payload, attempts`,
+		q.VisibilityTimeout.Milliseconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*postgresDelivery
+	for rows.Next() {
+		job := &postgresDelivery{queue: q}
+		if err := rows.Scan(&job.id, &job.body, &job.attempts); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not preserve the subquery's order
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].id < jobs[j].id })
+
+	q.mu.Lock()
+	for _, job := range jobs {
+		q.inflight[job.id] = true
+	}
+	q.mu.Unlock()
+	return jobs, nil
+}
+
+// postgresDelivery is a job claimed from a PostgresQueue
+type postgresDelivery struct {
+	queue    *PostgresQueue
+	id       int64
+	body     []byte
+	attempts int
+}
+
+func (d *postgresDelivery) Body() []byte    { return d.body }
+func (d *postgresDelivery) RetryCount() int { return d.attempts }
+
+// Ack removes the finished job
+func (d *postgresDelivery) Ack() error {
+	return d.exec("DELETE FROM jobs WHERE id = $1", d.id)
+}
+
+// Retry makes the job available again after an exponential backoff,
+// marking it dead once MaxRetries is used up
+func (d *postgresDelivery) Retry(reason string) error {
+	attempt := d.attempts + 1
+	if attempt > MaxRetries {
+		return d.DeadLetter(reason)
+	}
+
+	log.Printf("Retrying message in %s (attempt %d of %d): %s", retryDelay(attempt), attempt, MaxRetries, reason)
+	return d.exec(`UPDATE jobs SET attempts = $1, last_error = $2, locked_until = NULL,
+		available_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond' WHERE id = $4`,
+		attempt, reason, retryDelay(attempt).Milliseconds(), d.id)
+}
+
+// DeadLetter keeps the job in the table as dead, recording why
+func (d *postgresDelivery) DeadLetter(reason string) error {
+	log.Println("Dead-lettering message:", reason)
+	return d.exec("UPDATE jobs SET dead_at = CURRENT_TIMESTAMP, last_error = $1, locked_until = NULL WHERE id = $2", reason, d.id)
+}
+
+// errJobLost is returned when a job was no longer there to settle
+var errJobLost = errors.New("job no longer exists")
+
+// exec settles the job with sql. The job stops being kept alive first, so
+// its claim is not extended after it was released.
+func (d *postgresDelivery) exec(sql string, args ...any) error {
+	d.queue.release(d.id)
+	tag, err := d.queue.pool.Exec(context.Background(), sql, args...)
+	if err != nil {
+		log.Println("Failed to settle job:", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errJobLost
+	}
+	return nil
+}
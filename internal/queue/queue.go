@@ -0,0 +1,27 @@
+package queue
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+)
+
+// Publisher hands a request to the worker and tracks it as an operation.
+// The operation ID and idempotency key are set on env before it is queued.
+// When idempotencyKey already belongs to an operation, that operation is
+// returned with created set to false and nothing is queued.
+type Publisher interface {
+	Publish(opType string, idempotencyKey string, env *messages.Envelope) (op *models.Operation, created bool, err error)
+}
+
+// Delivery is a message received by the worker. Exactly one of Ack, Retry
+// or DeadLetter must be called to settle it.
+type Delivery interface {
+	Body() []byte
+	// RetryCount reports how many times the message has already been retried
+	RetryCount() int
+	Ack() error
+	// Retry schedules the message for redelivery after a backoff
+	Retry(reason string) error
+	// DeadLetter parks a message that cannot be processed, recording why
+	DeadLetter(reason string) error
+}
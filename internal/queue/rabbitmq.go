@@ -4,17 +4,37 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 )
 
-var conn *amqp091.Connection
-var channel *amqp091.Channel
+// rabbit is the shared connection opened by InitRabbitMQ
+var rabbit *connection
+
 var queueName = "transactions"
 
-// Initialize RabbitMQ connection
+// confirmTimeout bounds how long a publish waits for the broker to confirm it
+const confirmTimeout = 5 * time.Second
+
+// Failed messages are retried through per-attempt delay queues whose TTL
+// doubles each time; expired messages dead-letter back onto the main queue.
+// Once MaxRetries is exhausted, or for messages that can never succeed, they
+// are published to the dead-letter exchange with the failure reason.
+const (
+	MaxRetries     = 5
+	baseRetryDelay = time.Second
+
+	deadLetterExchange = "transactions.dlx"
+	deadLetterQueue    = "transactions.dead"
+
+	retryCountHeader    = "x-retry-count"
+	failureReasonHeader = "x-failure-reason"
+)
+
+// Initialize RabbitMQ connection. The connection is re-established
+// automatically if the broker closes it later.
 func InitRabbitMQ() {
-	var err error
 	rabbitmqHost := os.Getenv("RABBITMQ_HOST")
 	rabbitmqUser := os.Getenv("RABBITMQ_USER")
 	rabbitmqPass := os.Getenv("RABBITMQ_PASSWORD")
@@ -22,20 +42,80 @@ func InitRabbitMQ() {
 	// Construct the connection URL using the environment variables
 	amqpURL := fmt.Sprintf("amqp://%s:%s@%s:5672/", rabbitmqUser, rabbitmqPass, rabbitmqHost)
 
-	// Connect to RabbitMQ
-	conn, err = amqp091.Dial(amqpURL)
+	// Connect to RabbitMQ and declare queues and exchanges
+	var err error
+	rabbit, err = dial(amqpURL)
 	if err != nil {
 		log.Fatal("Failed to connect to RabbitMQ:", err)
 	}
 
-	// Open a channel
-	channel, err = conn.Channel()
+	log.Println("RabbitMQ initialized successfully!")
+}
+
+// PublishMessage publishes a persistent message and waits until the broker
+// confirms it has taken responsibility for it. It is safe for concurrent use.
+func PublishMessage(message string) error {
+	err := rabbit.publish("", queueName, amqp091.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte(message),
+	})
 	if err != nil {
-		log.Fatal("Failed to open a channel:", err)
+		log.Println("Failed to publish message:", err)
+		return err
 	}
 
-	// Declare a queue
-	_, err = channel.QueueDeclare(
+	log.Println("Message published to queue:", message)
+	return nil
+}
+
+// ConsumeMessages consumes messages from the queue. At most prefetch
+// deliveries are outstanding unacknowledged at a time. When the connection
+// or channel is lost the consumer resubscribes once it is back; deliveries
+// that were not acknowledged by then are redelivered by the broker.
+func ConsumeMessages(prefetch int) (<-chan Delivery, error) {
+	messages, err := rabbit.consume(prefetch)
+	if err != nil {
+		log.Println("Failed to consume messages:", err)
+		return nil, err
+	}
+
+	deliveries := make(chan Delivery)
+	go func() {
+		for {
+			for msg := range messages {
+				deliveries <- rabbitDelivery{msg}
+			}
+
+			log.Println("Consumer channel closed, resubscribing")
+			for {
+				time.Sleep(reconnectMinDelay)
+				messages, err = rabbit.consume(prefetch)
+				if err == nil {
+					break
+				}
+				log.Println("Failed to resubscribe:", err)
+			}
+		}
+	}()
+	return deliveries, nil
+}
+
+// rabbitDelivery settles RabbitMQ deliveries through the retry delay queues
+// and the dead-letter exchange
+type rabbitDelivery struct {
+	msg amqp091.Delivery
+}
+
+func (d rabbitDelivery) Body() []byte                   { return d.msg.Body }
+func (d rabbitDelivery) RetryCount() int                { return retryCount(d.msg) }
+func (d rabbitDelivery) Ack() error                     { return d.msg.Ack(false) }
+func (d rabbitDelivery) Retry(reason string) error      { return retry(d.msg, reason) }
+func (d rabbitDelivery) DeadLetter(reason string) error { return deadLetter(d.msg, reason) }
+
+// declareTopology declares the work queue, its retry delay queues and the
+// dead-letter exchange with the queue bound to it
+func declareTopology(channel *amqp091.Channel) error {
+	_, err := channel.QueueDeclare(
 		queueName,
 		true,  // Durable
 		false, // Auto-delete
@@ -43,50 +123,126 @@ func InitRabbitMQ() {
 		false, // No-wait
 		nil,
 	)
-
-	// Check for errors
 	if err != nil {
-		log.Fatal("Failed to declare queue:", err)
+		return err
 	}
 
-	log.Println("RabbitMQ initialized successfully!")
-}
+	for attempt := 1; attempt <= MaxRetries; attempt++ {
+		_, err = channel.QueueDeclare(
+			retryQueueName(attempt),
+			true,  // Durable
+			false, // Auto-delete
+			false, // Exclusive
+			false, // No-wait
+			amqp091.Table{
+				"x-message-ttl":             retryDelay(attempt).Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
 
-// Publish a message to RabbitMQ
-func PublishMessage(message string) error {
-	err := channel.Publish(
-		"",
-		queueName,
-		false,
-		false,
-		amqp091.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(message),
-		},
+	err = channel.ExchangeDeclare(
+		deadLetterExchange,
+		amqp091.ExchangeFanout,
+		true,  // Durable
+		false, // Auto-delete
+		false, // Internal
+		false, // No-wait
+		nil,
 	)
 	if err != nil {
-		log.Println("Failed to publish message:", err)
 		return err
 	}
 
-	log.Println("Message published to queue:", message)
-	return nil
-}
-
-// ConsumeMessages consumes messages from the queue
-func ConsumeMessages() (<-chan amqp091.Delivery, error) {
-	messages, err := channel.Consume(
-		queueName,
-		"",
-		false, // Manual acknowledgment
-		false,
-		false,
-		false,
+	_, err = channel.QueueDeclare(
+		deadLetterQueue,
+		true,  // Durable
+		false, // Auto-delete
+		false, // Exclusive
+		false, // No-wait
 		nil,
 	)
 	if err != nil {
-		log.Println("Failed to consume messages:", err)
-		return nil, err
+		return err
+	}
+
+	return channel.QueueBind(deadLetterQueue, "", deadLetterExchange, false, nil)
+}
+
+// retryDelay is the exponential backoff before the given retry attempt
+func retryDelay(attempt int) time.Duration {
+	return baseRetryDelay << (attempt - 1)
+}
+
+func retryQueueName(attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, attempt)
+}
+
+// retryCount reports how many times a delivery has already been retried
+func retryCount(msg amqp091.Delivery) int {
+	switch n := msg.Headers[retryCountHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+// retry schedules a failed delivery for redelivery after an exponential
+// backoff and acknowledges the original. Deliveries that have used up
+// MaxRetries are dead-lettered instead.
+func retry(msg amqp091.Delivery, reason string) error {
+	attempt := retryCount(msg) + 1
+	if attempt > MaxRetries {
+		return deadLetter(msg, reason)
+	}
+
+	headers := copyHeaders(msg.Headers)
+	headers[retryCountHeader] = int32(attempt)
+	headers[failureReasonHeader] = reason
+
+	log.Printf("Retrying message in %s (attempt %d of %d): %s", retryDelay(attempt), attempt, MaxRetries, reason)
+	return republish(msg, "", retryQueueName(attempt), headers)
+}
+
+// deadLetter moves a delivery that cannot be processed to the dead-letter
+// exchange, recording why, and acknowledges the original
+func deadLetter(msg amqp091.Delivery, reason string) error {
+	headers := copyHeaders(msg.Headers)
+	headers[failureReasonHeader] = reason
+	headers["x-dead-lettered-at"] = time.Now().UTC().Format(time.RFC3339)
+
+	log.Println("Dead-lettering message:", reason)
+	return republish(msg, deadLetterExchange, "", headers)
+}
+
+// republish copies a delivery to another destination and acks it once the
+// broker has confirmed the copy. If the publish fails the delivery is
+// requeued so it is not lost.
+func republish(msg amqp091.Delivery, exchange, routingKey string, headers amqp091.Table) error {
+	err := rabbit.publish(exchange, routingKey, amqp091.Publishing{
+		ContentType: msg.ContentType,
+		Headers:     headers,
+		Body:        msg.Body,
+	})
+	if err != nil {
+		log.Println("Failed to republish message:", err)
+		msg.Nack(false, true)
+		return err
+	}
+	return msg.Ack(false)
+}
+
+func copyHeaders(headers amqp091.Table) amqp091.Table {
+	copied := amqp091.Table{}
+	for k, v := range headers {
+		copied[k] = v
 	}
-	return messages, nil
+	return copied
 }
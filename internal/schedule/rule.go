@@ -0,0 +1,196 @@
+// Package schedule runs standing orders: deposits, withdrawals and transfers
+// repeated on a cron or interval rule.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinInterval is the shortest interval rule accepted
+const MinInterval = time.Minute
+
+// Rule yields the occurrences of a schedule
+type Rule interface {
+	// Next returns the first occurrence strictly after t
+	Next(t time.Time) time.Time
+}
+
+// ParseRule builds the rule of a schedule from either a cron expression or
+// an interval. Interval occurrences are counted from start.
+func ParseRule(cron string, interval time.Duration, start time.Time) (Rule, error) {
+	switch {
+	case cron != "" && interval != 0:
+		return nil, errors.New("use either cron or interval, not both")
+	case cron != "":
+		return ParseCron(cron)
+	case interval != 0:
+		if interval < MinInterval {
+			return nil, fmt.Errorf("interval must be at least %s", MinInterval)
+		}
+		return intervalRule{start: start, every: interval}, nil
+	default:
+		return nil, errors.New("cron or interval is required")
+	}
+}
+
+// First returns the first occurrence of rule at or after start
+func First(rule Rule, start time.Time) time.Time {
+	return rule.Next(start.Add(-time.Nanosecond))
+}
+
+// intervalRule repeats every fixed duration from start
+type intervalRule struct {
+	start time.Time
+	every time.Duration
+}
+
+func (r intervalRule) Next(t time.Time) time.Time {
+	if t.Before(r.start) {
+		return r.start
+	}
+	n := t.Sub(r.start)/r.every + 1
+	return r.start.Add(n * r.every)
+}
+
+// cronRule matches the standard five cron fields, evaluated in UTC. Each
+// field is a bitset of the values it allows.
+type cronRule struct {
+	minute, hour, dom, month, dow uint64
+	// Cron matches a day when either day field matches if both are
+	// restricted, and only the restricted one otherwise
+	domAny, dowAny bool
+}
+
+// cronMacros are shorthands for common expressions
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// ParseCron parses a five-field cron expression ("minute hour day-of-month
+// month day-of-week") in UTC. Fields accept *, numbers, ranges (1-5), lists
+// (1,15) and steps (*/15, 1-30/2). Sunday is 0 or 7.
+func ParseCron(expr string) (Rule, error) {
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have five fields", expr)
+	}
+
+	var r cronRule
+	var err error
+	if r.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if r.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if r.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if r.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if r.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	// Sunday may be written as 7
+	if r.dow&(1<<7) != 0 {
+		r.dow |= 1
+	}
+	r.domAny = fields[2] == "*"
+	r.dowAny = fields[4] == "*"
+	return r, nil
+}
+
+// parseField turns one cron field into a bitset of allowed values
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// maxSearch bounds the search for rules that can never match, such as
+// February 30th
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+func (r cronRule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		switch {
+		case r.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !r.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case r.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case r.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (r cronRule) dayMatches(t time.Time) bool {
+	dom := r.dom&(1<<t.Day()) != 0
+	dow := r.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case r.domAny && r.dowAny:
+		return true
+	case r.domAny:
+		return dow
+	case r.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
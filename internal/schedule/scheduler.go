@@ -0,0 +1,156 @@
+package schedule
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// maxCatchUp bounds the missed occurrences of one schedule queued per run,
+// the rest follow on the next runs
+const maxCatchUp = 100
+
+// Scheduler queues the due occurrences of active schedules. Every occurrence
+// is published with an idempotency key made of the schedule ID and the
+// occurrence time, so it becomes exactly one operation even when several
+// schedulers run or one crashes before advancing the schedule.
+type Scheduler struct {
+	Schedules storage.ScheduleRepository
+	Publisher queue.Publisher
+	Interval  time.Duration // How often to look for due schedules
+	BatchSize int           // Maximum schedules handled per run
+}
+
+// Run queues due occurrences until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunDue(time.Now()); err != nil {
+			log.Println("Scheduler run failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue queues every occurrence up to now of the schedules due at now and
+// moves each schedule on to its next occurrence. It returns the number of
+// operations queued.
+func (s *Scheduler) RunDue(now time.Time) (int, error) {
+	due, err := s.Schedules.DueSchedules(now, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, sched := range due {
+		n, err := s.runSchedule(sched, now)
+		queued += n
+		if err != nil {
+			log.Printf("Failed to run schedule %d: %v", sched.ID, err)
+		}
+	}
+	return queued, nil
+}
+
+// runSchedule queues the occurrences of one due schedule and advances it
+// past those that were queued
+func (s *Scheduler) runSchedule(sched models.Schedule, now time.Time) (int, error) {
+	rule, err := RuleOf(&sched)
+	if err != nil {
+		return 0, err
+	}
+
+	from := *sched.NextRunAt
+	next := &from
+	queued := 0
+	var runErr error
+	for queued < maxCatchUp && next != nil && !next.After(now) {
+		if runErr = s.publish(&sched, *next); runErr != nil {
+			break
+		}
+		queued++
+		next = following(&sched, rule, *next)
+	}
+
+	if next == nil || !next.Equal(from) {
+		if _, err := s.Schedules.AdvanceSchedule(sched.ID, from, next); err != nil {
+			return queued, err
+		}
+	}
+	return queued, runErr
+}
+
+// publish queues the operation of one occurrence
+func (s *Scheduler) publish(sched *models.Schedule, at time.Time) error {
+	msg := MessageOf(sched)
+	env, err := messages.New(msg, "schedule:"+strconv.Itoa(sched.ID))
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("schedule:%d:%s", sched.ID, at.UTC().Format(time.RFC3339))
+	_, _, err = s.Publisher.Publish(msg.Type(), key, env)
+	return err
+}
+
+// RuleOf returns the rule of a stored schedule
+func RuleOf(sched *models.Schedule) (Rule, error) {
+	return ParseRule(sched.Cron, time.Duration(sched.IntervalSeconds)*time.Second, sched.StartAt)
+}
+
+// MessageOf builds the queue message for one occurrence of a schedule
+func MessageOf(sched *models.Schedule) messages.Message {
+	switch sched.Type {
+	case messages.TypeWithdraw:
+		return messages.Withdraw{AccountID: sched.AccountID, Amount: sched.Amount}
+	case messages.TypeTransfer:
+		var to int
+		if sched.ToAccountID != nil {
+			to = *sched.ToAccountID
+		}
+		return messages.Transfer{FromAccountID: sched.AccountID, ToAccountID: to, Amount: sched.Amount}
+	default:
+		return messages.Deposit{AccountID: sched.AccountID, Amount: sched.Amount}
+	}
+}
+
+// NextRun returns the first occurrence of a schedule at or after t, or nil
+// when it has none left before its end
+func NextRun(sched *models.Schedule, t time.Time) (*time.Time, error) {
+	rule, err := RuleOf(sched)
+	if err != nil {
+		return nil, err
+	}
+	if t.Before(sched.StartAt) {
+		t = sched.StartAt
+	}
+	return within(sched, First(rule, t)), nil
+}
+
+// following returns the occurrence after at, or nil when there is none
+func following(sched *models.Schedule, rule Rule, at time.Time) *time.Time {
+	return within(sched, rule.Next(at))
+}
+
+// within drops an occurrence past the schedule's end, or the zero time of a
+// rule that never matches
+func within(sched *models.Schedule, at time.Time) *time.Time {
+	if at.IsZero() || sched.EndAt != nil && at.After(*sched.EndAt) {
+		return nil
+	}
+	at = at.UTC()
+	return &at
+}
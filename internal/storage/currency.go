@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCurrencyMismatch is returned when a transaction is in another currency
+// than its account, or a transfer is between accounts in different
+// currencies
+var ErrCurrencyMismatch = errors.New("currency does not match the account")
+
+// ErrInvalidAmount is returned when an amount is finer than its account's
+// currency allows, such as a fraction of a yen
+var ErrInvalidAmount = errors.New("amount does not suit the account's currency")
+
+// accountCurrency returns the currency of an account
+func accountCurrency(ctx context.Context, q querier, id int) (string, error) {
+	var code string
+	if err := q.QueryRow(ctx, "SELECT currency FROM accounts WHERE id = $1", id).Scan(&code); err != nil {
+		return "", fmt.Errorf("account %d: %w", id, notFound(err))
+	}
+	return code, nil
+}
+
+// checkAmount fails with ErrInvalidAmount unless amount can be held in the
+// account's currency
+func checkAmount(ctx context.Context, q querier, id int, amount models.Money) error {
+	code, err := accountCurrency(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	return amountRule(code, amount)
+}
+
+// checkTransferCurrency fails with ErrCurrencyMismatch unless both accounts
+// of a transfer hold the same currency, and with ErrInvalidAmount unless
+// amount can be held in it
+func checkTransferCurrency(ctx context.Context, q querier, fromID, toID int, amount models.Money) error {
+	from, err := accountCurrency(ctx, q, fromID)
+	if err != nil {
+		return err
+	}
+	to, err := accountCurrency(ctx, q, toID)
+	if err != nil {
+		return err
+	}
+	if from != to {
+		return fmt.Errorf("%w: cannot transfer %s to a %s account", ErrCurrencyMismatch, from, to)
+	}
+	return amountRule(from, amount)
+}
+
+// amountRule applies currency.CheckAmount
+func amountRule(code string, amount models.Money) error {
+	if err := currency.CheckAmount(code, amount); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	return nil
+}
+
+// openingCurrency returns the currency an account on product opens in, the
+// product's when code is empty, and fails unless balance can be held in it
+func openingCurrency(product models.Product, code string, balance models.Money) (string, error) {
+	if code == "" {
+		code = product.Currency
+	}
+	if err := amountRule(code, balance); err != nil {
+		return "", err
+	}
+	return code, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrCustomerHasAccounts is returned when deleting a customer who holds
+// accounts. Customers of closed accounts are kept for the record.
+var ErrCustomerHasAccounts = errors.New("customer holds accounts")
+
+// CreateCustomer stores a new customer
+func CreateCustomer(c models.Customer) (*models.Customer, error) {
+	return scanCustomer(DB.QueryRow(context.Background(),
+		`INSERT INTO customers (legal_name, email, phone, address, date_of_birth)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, '')::date) RETURNING `+customerColumns,
+		c.LegalName, c.Email, c.Phone, c.Address, c.DateOfBirth))
+}
+
+// GetCustomer fetches a customer by ID
+func GetCustomer(id int) (*models.Customer, error) {
+	return scanCustomer(DB.QueryRow(context.Background(), "SELECT "+customerColumns+" FROM customers WHERE id = $1", id))
+}
+
+// ListCustomers returns every customer, oldest first
+func ListCustomers() ([]models.Customer, error) {
+	rows, err := DB.Query(context.Background(), "SELECT "+customerColumns+" FROM customers ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, *c)
+	}
+	return customers, rows.Err()
+}
+
+// UpdateCustomer replaces a customer's details
+func UpdateCustomer(c models.Customer) (*models.Customer, error) {
+	return scanCustomer(DB.QueryRow(context.Background(),
+		`UPDATE customers SET legal_name = $2, email = NULLIF($3, ''), phone = NULLIF($4, ''), address = NULLIF($5, ''),
+			date_of_birth = NULLIF($6, '')::date, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 RETURNING `+customerColumns,
+		c.ID, c.LegalName, c.Email, c.Phone, c.Address, c.DateOfBirth))
+}
+
+// DeleteCustomer removes a customer who holds no accounts
+func DeleteCustomer(id int) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	var hasAccounts bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM accounts WHERE customer_id = customers.id) FROM customers WHERE id = $1 FOR UPDATE", id).
+		Scan(&hasAccounts)
+	if err != nil {
+		return notFound(err)
+	}
+	if hasAccounts {
+		return ErrCustomerHasAccounts
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM customers WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListCustomerAccounts returns a customer's accounts, including closed ones
+func ListCustomerAccounts(customerID int) ([]models.Account, error) {
+	if _, err := GetCustomer(customerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(context.Background(), "SELECT "+accountColumns+" FROM accounts WHERE customer_id = $1 ORDER BY id", customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.Account{}
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *acc)
+	}
+	return accounts, rows.Err()
+}
+
+const customerColumns = "id, legal_name, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(address, ''), COALESCE(to_char(date_of_birth, 'YYYY-MM-DD'), ''), created_at, updated_at"
+
+func scanCustomer(row pgx.Row) (*models.Customer, error) {
+	var c models.Customer
+	err := row.Scan(&c.ID, &c.LegalName, &c.Email, &c.Phone, &c.Address, &c.DateOfBirth, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
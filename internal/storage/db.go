@@ -1,17 +1,25 @@
 package storage
 
 import (
+	"banking-ledger-service/internal/fees"
 	"banking-ledger-service/internal/models"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var DB *pgxpool.Pool
 
+// ErrInsufficientFunds is returned when a debit would take an account past
+// its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 // InitDB initializes the PostgreSQL database connection
 func InitDB() {
 	host := os.Getenv("DB_HOST")
@@ -45,8 +53,22 @@ func InitDB() {
 	log.Println("Connected to PostgreSQL")
 }
 
-// CreateAccount inserts a new account while ensuring uniqueness
-func CreateAccount(name string, balance float64) (int, error) {
+// CreateAccount inserts a new account for a customer while ensuring its name
+// is unique among the customer's accounts. Without a customer, a new one
+// named after the account is created; without a product code, the default
+// product is used, and without a currency, the product's. A repeated
+// idempotency key returns the originally created account ID with
+// ErrDuplicateRequest.
+func CreateAccount(customerID *int, name, productCode, currencyCode string, balance models.Money, idempotencyKey string) (int, error) {
+	product, err := openingProduct(productCode, balance)
+	if err != nil {
+		return 0, err
+	}
+	currencyCode, err = openingCurrency(product, currencyCode, balance)
+	if err != nil {
+		return 0, err
+	}
+
 	var id int
 	tx, err := DB.Begin(context.Background())
 	if err != nil {
@@ -56,21 +78,61 @@ func CreateAccount(name string, balance float64) (int, error) {
 	// Rollback transaction if any error occurs
 	defer tx.Rollback(context.Background())
 
-	// Insert new account
-	err = tx.QueryRow(context.Background(), "INSERT INTO accounts (name, balance) VALUES ($1, $2) RETURNING id", name, balance).Scan(&id)
-	if err != nil {
-		return 0, err
+	if existing, err := claimIdempotencyKey(context.Background(), tx, idempotencyKey, 0); err != nil {
+		return existing, err
 	}
 
-	// Commit transaction
-	err = tx.Commit(context.Background())
+	if customerID == nil {
+		err = tx.QueryRow(context.Background(), "INSERT INTO customers (legal_name) VALUES ($1) RETURNING id", name).Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+		customerID = &id
+	}
+
+	// Insert new account; its opening balance is posted below
+	err = tx.QueryRow(context.Background(), "INSERT INTO accounts (name, customer_id, product_code, currency) VALUES ($1, $2, $3, $4) RETURNING id", name, *customerID, product.Code, currencyCode).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return 0, ErrAccountExists
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return 0, fmt.Errorf("customer %d: %w", *customerID, ErrNotFound)
+	}
 	if err != nil {
 		return 0, err
 	}
 
+	if idempotencyKey != "" {
+		_, err = tx.Exec(context.Background(), "UPDATE idempotency_keys SET account_id = $1 WHERE key = $2", id, idempotencyKey)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Fund the opening balance from the cash-in system account
+	entryID := 0
+	if balance != 0 {
+		cashIn, err := systemAccountID(context.Background(), tx, CashInAccount, id)
+		if err != nil {
+			return 0, err
+		}
+		entryID, _, err = postEntry(context.Background(), tx, "account opening deposit",
+			posting{accountID: cashIn, amount: -balance},
+			posting{accountID: id, amount: balance})
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	// Add transaction record
-	err = AddTransaction(id, balance, "account_creation")
+	err = addTransaction(context.Background(), tx, id, balance, "account_creation", entryID, &balance)
+	if err != nil {
+		return 0, err
+	}
 
+	// Commit transaction
+	err = tx.Commit(context.Background())
 	if err != nil {
 		return 0, err
 	}
@@ -78,46 +140,231 @@ func CreateAccount(name string, balance float64) (int, error) {
 	return id, nil
 }
 
-// Fetch account by ID
+// Fetch account by ID, with its balance available after active holds and
+// its overdraft limit
 func GetAccount(id int) (*models.Account, error) {
+	return scanAccount(DB.QueryRow(context.Background(), "SELECT "+accountColumns+" FROM accounts WHERE id = $1 AND NOT is_system", id))
+}
+
+const accountColumns = "id, name, customer_id, product_code, currency, status, balance, balance + overdraft_limit - " + heldSum + ", account_type, interest_product_id, overdraft_limit, overdraft_rate_bps"
+
+func scanAccount(row pgx.Row) (*models.Account, error) {
 	var acc models.Account
-	err := DB.QueryRow(context.Background(), "SELECT id, name, balance FROM accounts WHERE id = $1", id).
-		Scan(&acc.ID, &acc.Name, &acc.Balance)
+	err := row.Scan(&acc.ID, &acc.Name, &acc.CustomerID, &acc.ProductCode, &acc.Currency, &acc.Status, &acc.Balance, &acc.AvailableBalance, &acc.AccountType, &acc.InterestProductID, &acc.OverdraftLimit, &acc.OverdraftRateBps)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &acc, nil
 }
 
-// Update Balance function for deposits & withdrawals
-func UpdateBalance(accountID int, amount float64, operation string) error {
+// SetAccountType changes the type of an account, which selects the fee
+// rules that apply to it
+func SetAccountType(id int, accountType string) error {
+	tag, err := DB.Exec(context.Background(), "UPDATE accounts SET account_type = $1 WHERE id = $2 AND NOT is_system", accountType, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	_, err := DB.Begin(context.Background())
+// Update Balance function for deposits & withdrawals. The account row is
+// locked for the rest of the transaction so the balance check, the journal
+// entry and the transaction record are applied atomically; a withdrawal of
+// more than the balance available after active holds and within the
+// overdraft limit returns ErrInsufficientFunds, a change the account's
+// status does not allow returns ErrAccountNotActive, one its product does
+// not allow returns ErrProductRule, and an amount finer than its currency
+// allows returns ErrInvalidAmount. A repeated idempotency key leaves
+// the balance untouched and returns ErrDuplicateRequest.
+func UpdateBalance(accountID int, amount models.Money, operation string, idempotencyKey string) error {
+	tx, err := DB.Begin(context.Background())
 	if err != nil {
 		return err
 	}
 
-	var query string
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(context.Background())
+
+	if _, err := claimIdempotencyKey(context.Background(), tx, idempotencyKey, accountID); err != nil {
+		return err
+	}
+
+	available, err := lockAvailable(context.Background(), tx, accountID)
+	if err != nil {
+		return err
+	}
+	if err := requireStatus(context.Background(), tx, accountID, operation == "withdraw"); err != nil {
+		return err
+	}
+	if err := checkAmount(context.Background(), tx, accountID, amount); err != nil {
+		return err
+	}
+
+	// Fees are charged in this transaction and must be covered too
+	charges, err := dueFees(context.Background(), tx, accountID, operation, amount)
+	if err != nil {
+		return err
+	}
+	due := fees.Total(charges)
+	if operation == "withdraw" && available < amount+due || operation == "deposit" && due > 0 && available+amount < due {
+		return ErrInsufficientFunds
+	}
+	if err := checkProduct(context.Background(), tx, accountID, operation, amount, available-due); err != nil {
+		return err
+	}
+
+	// Deposits are funded from cash-in and withdrawals paid to cash-out
+	var entryID int
+	var balances map[int]models.Money
 	if operation == "deposit" {
-		query = "UPDATE accounts SET balance = balance + $1 WHERE id = $2"
+		cashIn, err := systemAccountID(context.Background(), tx, CashInAccount, accountID)
+		if err != nil {
+			return err
+		}
+		entryID, balances, err = postEntry(context.Background(), tx, "deposit",
+			posting{accountID: cashIn, amount: -amount},
+			posting{accountID: accountID, amount: amount})
+		if err != nil {
+			return err
+		}
 	} else if operation == "withdraw" {
-		query = "UPDATE accounts SET balance = balance - $1 WHERE id = $2"
+		cashOut, err := systemAccountID(context.Background(), tx, CashOutAccount, accountID)
+		if err != nil {
+			return err
+		}
+		entryID, balances, err = postEntry(context.Background(), tx, "withdrawal",
+			posting{accountID: accountID, amount: -amount},
+			posting{accountID: cashOut, amount: amount})
+		if err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("unsupported balance operation %q", operation)
 	}
 
-	err = AddTransaction(accountID, amount, operation)
-
+	var transactionID int
+	err = tx.QueryRow(context.Background(),
+		"INSERT INTO transactions (account_id, currency, amount, type, entry_id, balance_after) VALUES ($1, (SELECT currency FROM accounts WHERE id = $1), $2, $3, $4, $5) RETURNING id",
+		accountID, amount, operation, entryID, balances[accountID]).Scan(&transactionID)
 	if err != nil {
 		return err
 	}
+	if err := chargeFees(context.Background(), tx, accountID, transactionID, charges); err != nil {
+		return err
+	}
 
-	_, err = DB.Exec(context.Background(), query, amount, accountID)
-	return err
+	return tx.Commit(context.Background())
 }
 
 // Add Transaction Record
-func AddTransaction(accountID int, amount float64, txType string) error {
-	l := fmt.Sprintf("INSERT INTO transactions (account_id, amount, type) VALUES (%v, %v, %v)\n", accountID, amount, txType)
+func AddTransaction(accountID int, amount models.Money, txType string) error {
+	return addTransaction(context.Background(), DB, accountID, amount, txType, 0, nil)
+}
+
+// execer is satisfied by both the connection pool and an open transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// addTransaction records a customer-facing transaction row, linked to the
+// journal entry that moved the money when entryID is non-zero and carrying
+// the account balance once it was applied
+func addTransaction(ctx context.Context, db execer, accountID int, amount models.Money, txType string, entryID int, balanceAfter *models.Money) error {
+	l := fmt.Sprintf("INSERT INTO transactions (account_id, amount, type, entry_id) VALUES (%v, %v, %v, %v)\n", accountID, amount, txType, entryID)
 	log.Println(l)
-	_, err := DB.Exec(context.Background(), "INSERT INTO transactions (account_id, amount, type) VALUES ($1, $2, $3)", accountID, amount, txType)
+	_, err := db.Exec(ctx, "INSERT INTO transactions (account_id, currency, amount, type, entry_id, balance_after) VALUES ($1, (SELECT currency FROM accounts WHERE id = $1), $2, $3, NULLIF($4, 0), $5)",
+		accountID, amount, txType, entryID, balanceAfter)
 	return err
 }
+
+// Transfer moves funds between two accounts in a single database transaction.
+// Both account rows are locked in ascending id order so that concurrent
+// transfers in opposite directions cannot deadlock. The source account must
+// allow debits and the destination credits, by both status and product,
+// and both must hold the same currency, else ErrCurrencyMismatch.
+//
+// A repeated idempotency key leaves both balances untouched and returns
+// ErrDuplicateRequest.
+func Transfer(fromID, toID int, amount models.Money, idempotencyKey string) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	if _, err := claimIdempotencyKey(ctx, tx, idempotencyKey, fromID); err != nil {
+		return err
+	}
+
+	lockOrder := []int{fromID, toID}
+	if toID < fromID {
+		lockOrder = []int{toID, fromID}
+	}
+
+	for _, id := range lockOrder {
+		if _, err := lockAccount(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	if err := requireStatus(ctx, tx, fromID, true); err != nil {
+		return err
+	}
+	if err := requireStatus(ctx, tx, toID, false); err != nil {
+		return err
+	}
+	if err := checkTransferCurrency(ctx, tx, fromID, toID, amount); err != nil {
+		return err
+	}
+
+	// Funds reserved by holds cannot be transferred
+	available, err := lockAvailable(ctx, tx, fromID)
+	if err != nil {
+		return err
+	}
+	if available < amount {
+		return ErrInsufficientFunds
+	}
+	if err := checkProduct(ctx, tx, fromID, "transfer_out", amount, available); err != nil {
+		return err
+	}
+	if err := checkProduct(ctx, tx, toID, "transfer_in", amount, 0); err != nil {
+		return err
+	}
+
+	entryID, balances, err := postEntry(ctx, tx, "transfer",
+		posting{accountID: fromID, amount: -amount},
+		posting{accountID: toID, amount: amount})
+	if err != nil {
+		return err
+	}
+
+	// Record both sides of the transfer
+	fromAfter, toAfter := balances[fromID], balances[toID]
+	err = addTransaction(ctx, tx, fromID, amount, "transfer_out", entryID, &fromAfter)
+	if err != nil {
+		return err
+	}
+	err = addTransaction(ctx, tx, toID, amount, "transfer_in", entryID, &toAfter)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockAccount takes a row lock on a customer account until tx ends and
+// returns its current balance
+func lockAccount(ctx context.Context, tx pgx.Tx, id int) (models.Money, error) {
+	var balance models.Money
+	err := tx.QueryRow(ctx, "SELECT balance FROM accounts WHERE id = $1 AND NOT is_system FOR UPDATE", id).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("lock account %d: %w", id, notFound(err))
+	}
+	return balance, nil
+}
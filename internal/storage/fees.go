@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/fees"
+	"banking-ledger-service/internal/lifecycle"
+	"banking-ledger-service/internal/models"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// querier is satisfied by both the connection pool and an open transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// CreateFeeRule stores a new active fee rule
+func CreateFeeRule(r models.FeeRule) (*models.FeeRule, error) {
+	return scanFeeRule(DB.QueryRow(context.Background(),
+		`INSERT INTO fee_rules (name, transaction_type, account_type, min_amount, free_per_month, flat_fee, rate_bps, max_fee)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8) RETURNING `+feeRuleColumns,
+		r.Name, r.TransactionType, r.AccountType, r.MinAmount, r.FreePerMonth, r.FlatFee, r.RateBps, r.MaxFee))
+}
+
+// ListFeeRules returns every fee rule, including deactivated ones
+func ListFeeRules() ([]models.FeeRule, error) {
+	return queryFeeRules(context.Background(), DB, "SELECT "+feeRuleColumns+" FROM fee_rules ORDER BY id")
+}
+
+// DeactivateFeeRule stops a fee rule from charging any further fees
+func DeactivateFeeRule(id int) (*models.FeeRule, error) {
+	return scanFeeRule(DB.QueryRow(context.Background(), "UPDATE fee_rules SET active = FALSE WHERE id = $1 RETURNING "+feeRuleColumns, id))
+}
+
+// PreviewFees returns the fees a deposit or withdrawal of amount would be
+// charged if it were processed now
+func PreviewFees(accountID int, txType string, amount models.Money) ([]models.FeeCharge, error) {
+	return dueFees(context.Background(), DB, accountID, txType, amount)
+}
+
+// dueFees evaluates the active fee rules for a transaction on an account
+// against the transactions of the same type it made this calendar month.
+// Accounts whose status blocks debits, such as frozen ones, are charged no
+// fees.
+func dueFees(ctx context.Context, q querier, accountID int, txType string, amount models.Money) ([]models.FeeCharge, error) {
+	var accountType, code, status string
+	var monthCount int
+	err := q.QueryRow(ctx,
+		`SELECT account_type, currency, status, (SELECT COUNT(*) FROM transactions
+			WHERE account_id = accounts.id AND type = $2 AND created_at >= date_trunc('month', CURRENT_TIMESTAMP))
+		FROM accounts WHERE id = $1 AND NOT is_system`,
+		accountID, txType).Scan(&accountType, &code, &status, &monthCount)
+	if err != nil {
+		return nil, fmt.Errorf("account %d: %w", accountID, notFound(err))
+	}
+	if !lifecycle.AllowsDebit(status) {
+		return []models.FeeCharge{}, nil
+	}
+
+	rules, err := queryFeeRules(ctx, q,
+		"SELECT "+feeRuleColumns+" FROM fee_rules WHERE active AND transaction_type = $1 AND (account_type IS NULL OR account_type = $2) ORDER BY id",
+		txType, accountType)
+	if err != nil {
+		return nil, err
+	}
+	return roundCharges(code, fees.Evaluate(rules, txType, accountType, amount, monthCount)), nil
+}
+
+// roundCharges rounds fee charges to the nearest unit of currency code,
+// dropping any that round to nothing
+func roundCharges(code string, charges []models.FeeCharge) []models.FeeCharge {
+	rounded := charges[:0]
+	for _, c := range charges {
+		c.Amount = currency.Round(code, c.Amount)
+		if c.Amount > 0 {
+			rounded = append(rounded, c)
+		}
+	}
+	return rounded
+}
+
+// chargeFees debits each fee from the account, paying it to the fees system
+// account, and records it as a fee transaction linked to transactionID
+func chargeFees(ctx context.Context, tx pgx.Tx, accountID, transactionID int, charges []models.FeeCharge) error {
+	if len(charges) == 0 {
+		return nil
+	}
+	feesID, err := systemAccountID(ctx, tx, FeesAccount, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range charges {
+		entryID, balances, err := postEntry(ctx, tx, "fee: "+c.Name,
+			posting{accountID: accountID, amount: -c.Amount},
+			posting{accountID: feesID, amount: c.Amount})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx,
+			"INSERT INTO transactions (account_id, currency, amount, type, entry_id, balance_after, fee_for, reason) VALUES ($1, (SELECT currency FROM accounts WHERE id = $1), $2, 'fee', $3, $4, $5, $6)",
+			accountID, c.Amount, entryID, balances[accountID], transactionID, c.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const feeRuleColumns = "id, name, transaction_type, COALESCE(account_type, ''), min_amount, free_per_month, flat_fee, rate_bps, max_fee, active, created_at"
+
+func scanFeeRule(row pgx.Row) (*models.FeeRule, error) {
+	var r models.FeeRule
+	err := row.Scan(&r.ID, &r.Name, &r.TransactionType, &r.AccountType, &r.MinAmount, &r.FreePerMonth, &r.FlatFee, &r.RateBps, &r.MaxFee, &r.Active, &r.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &r, nil
+}
+
+func queryFeeRules(ctx context.Context, q querier, sql string, args ...any) ([]models.FeeRule, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.FeeRule{}
+	for rows.Next() {
+		r, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrHoldNotActive is returned when capturing or releasing a hold that was
+// already captured, released or has expired
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// ErrCaptureExceedsHold is returned when a capture is larger than its hold
+var ErrCaptureExceedsHold = errors.New("capture amount exceeds hold")
+
+// heldAmount sums the active, unexpired holds on an account
+func heldAmount(ctx context.Context, tx pgx.Tx, accountID int) (models.Money, error) {
+	var held models.Money
+	err := tx.QueryRow(ctx, "SELECT "+heldSum+" FROM accounts WHERE id = $1", accountID).Scan(&held)
+	return held, err
+}
+
+// heldSum is the SQL expression for the funds held on accounts.id
+const heldSum = `COALESCE((SELECT SUM(amount) FROM holds
+	WHERE holds.account_id = accounts.id AND status = 'active' AND expires_at > CURRENT_TIMESTAMP), 0)`
+
+// lockAvailable locks a customer account like lockAccount and returns its
+// balance less active holds plus its overdraft limit, the most a debit may
+// take from it
+func lockAvailable(ctx context.Context, tx pgx.Tx, id int) (models.Money, error) {
+	balance, err := lockAccount(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	held, err := heldAmount(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	var limit models.Money
+	if err := tx.QueryRow(ctx, "SELECT overdraft_limit FROM accounts WHERE id = $1", id).Scan(&limit); err != nil {
+		return 0, err
+	}
+	return balance - held + limit, nil
+}
+
+// PlaceHold reserves amount on an account until expiresAt. The account row
+// is locked while the available balance is checked, so holds, withdrawals
+// and transfers cannot together overdraw it.
+func PlaceHold(accountID int, amount models.Money, reference string, expiresAt time.Time) (*models.Hold, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	available, err := lockAvailable(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStatus(ctx, tx, accountID, true); err != nil {
+		return nil, err
+	}
+	if err := checkAmount(ctx, tx, accountID, amount); err != nil {
+		return nil, err
+	}
+	if available < amount {
+		return nil, ErrInsufficientFunds
+	}
+	if err := checkProduct(ctx, tx, accountID, "capture", amount, available); err != nil {
+		return nil, err
+	}
+
+	hold, err := scanHold(tx.QueryRow(ctx,
+		"INSERT INTO holds (account_id, amount, reference, expires_at) VALUES ($1, $2, NULLIF($3, ''), $4) RETURNING "+holdColumns,
+		accountID, amount, reference, expiresAt.UTC()))
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, tx.Commit(ctx)
+}
+
+// GetHold fetches a hold by ID
+func GetHold(id int) (*models.Hold, error) {
+	return scanHold(DB.QueryRow(context.Background(), "SELECT "+holdColumns+" FROM holds WHERE id = $1", id))
+}
+
+// CaptureHold debits amount of an active hold from its account, paying it
+// to the cash-out system account, and ends the hold. A zero amount captures
+// the full hold; after a partial capture the remainder is released.
+func CaptureHold(id int, amount models.Money) (*models.Hold, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	hold, err := lockHold(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	if err := requireStatus(ctx, tx, hold.AccountID, true); err != nil {
+		return nil, err
+	}
+	if err := checkAmount(ctx, tx, hold.AccountID, amount); err != nil {
+		return nil, err
+	}
+	cashOut, err := systemAccountID(ctx, tx, CashOutAccount, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	entryID, balances, err := postEntry(ctx, tx, "hold capture",
+		posting{accountID: hold.AccountID, amount: -amount},
+		posting{accountID: cashOut, amount: amount})
+	if err != nil {
+		return nil, err
+	}
+
+	balanceAfter := balances[hold.AccountID]
+	err = addTransaction(ctx, tx, hold.AccountID, amount, "capture", entryID, &balanceAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err = scanHold(tx.QueryRow(ctx,
+		"UPDATE holds SET status = 'captured', captured_amount = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING "+holdColumns,
+		amount, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, tx.Commit(ctx)
+}
+
+// ReleaseHold ends an active hold without moving any funds
+func ReleaseHold(id int) (*models.Hold, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	if _, err := lockHold(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	hold, err := scanHold(tx.QueryRow(ctx,
+		"UPDATE holds SET status = 'released', updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING "+holdColumns, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, tx.Commit(ctx)
+}
+
+// lockHold locks a hold row until tx ends, failing unless it is active
+func lockHold(ctx context.Context, tx pgx.Tx, id int) (*models.Hold, error) {
+	hold, err := scanHold(tx.QueryRow(ctx, "SELECT "+holdColumns+" FROM holds WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return nil, fmt.Errorf("lock hold %d: %w", id, err)
+	}
+	if hold.Status != models.HoldActive {
+		return nil, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+const holdColumns = "id, account_id, amount, captured_amount, COALESCE(reference, ''), status, expires_at, created_at, updated_at"
+
+// scanHold reads a hold, reporting active holds past their expiry as expired
+func scanHold(row pgx.Row) (*models.Hold, error) {
+	var h models.Hold
+	err := row.Scan(&h.ID, &h.AccountID, &h.Amount, &h.CapturedAmount, &h.Reference, &h.Status, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if h.Status == models.HoldActive && !h.ExpiresAt.After(time.Now()) {
+		h.Status = models.HoldExpired
+	}
+	return &h, nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDuplicateRequest is returned when a request carrying an idempotency key
+// that has already been applied is processed again
+var ErrDuplicateRequest = errors.New("request already processed")
+
+// claimIdempotencyKey records key inside tx so that it commits or rolls back
+// together with the change it guards. If the key was already applied the
+// account ID stored with it is returned alongside ErrDuplicateRequest.
+// An empty key is never deduplicated.
+func claimIdempotencyKey(ctx context.Context, tx pgx.Tx, key string, accountID int) (int, error) {
+	if key == "" {
+		return 0, nil
+	}
+
+	tag, err := tx.Exec(ctx, "INSERT INTO idempotency_keys (key, account_id) VALUES ($1, NULLIF($2, 0)) ON CONFLICT (key) DO NOTHING", key, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() == 1 {
+		return 0, nil
+	}
+
+	var existing *int
+	err = tx.QueryRow(ctx, "SELECT account_id FROM idempotency_keys WHERE key = $1", key).Scan(&existing)
+	if err != nil {
+		return 0, err
+	}
+	if existing == nil {
+		return 0, ErrDuplicateRequest
+	}
+	return *existing, ErrDuplicateRequest
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrProductExists is returned when an interest product name is taken
+var ErrProductExists = errors.New("interest product already exists")
+
+// InterestAccount is an account the interest engine accrues or posts for.
+// ProductID is zero when the product was removed with accruals still
+// unposted.
+type InterestAccount struct {
+	AccountID      int
+	ProductID      int
+	Since          time.Time  // Day interest started accruing under the product
+	AccruedThrough *time.Time // Last day accrued, if any
+}
+
+// CreateInterestProduct stores a new interest product
+func CreateInterestProduct(p models.InterestProduct) (*models.InterestProduct, error) {
+	if p.Tiers == nil {
+		p.Tiers = []models.InterestTier{}
+	}
+	product, err := scanInterestProduct(DB.QueryRow(context.Background(),
+		"INSERT INTO interest_products (name, annual_rate_bps, day_count, compounding, tiers) VALUES ($1, $2, $3, $4, $5) RETURNING "+interestProductColumns,
+		p.Name, p.AnnualRateBps, p.DayCount, p.Compounding, p.Tiers))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrProductExists
+	}
+	return product, err
+}
+
+// GetInterestProduct fetches an interest product by ID
+func GetInterestProduct(id int) (*models.InterestProduct, error) {
+	return scanInterestProduct(DB.QueryRow(context.Background(), "SELECT "+interestProductColumns+" FROM interest_products WHERE id = $1", id))
+}
+
+// ListInterestProducts returns every interest product
+func ListInterestProducts() ([]models.InterestProduct, error) {
+	rows, err := DB.Query(context.Background(), "SELECT "+interestProductColumns+" FROM interest_products ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.InterestProduct{}
+	for rows.Next() {
+		p, err := scanInterestProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+	return products, rows.Err()
+}
+
+// SetInterestProduct moves an account onto an interest product from since,
+// or off interest altogether when productID is nil
+func SetInterestProduct(accountID int, productID *int, since time.Time) error {
+	tag, err := DB.Exec(context.Background(),
+		"UPDATE accounts SET interest_product_id = $1, interest_since = CASE WHEN $1::int IS NULL THEN NULL ELSE $2::date END WHERE id = $3 AND NOT is_system",
+		productID, since.UTC(), accountID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// InterestAccounts returns the accounts with an interest product or with
+// accruals that were never posted
+func InterestAccounts() ([]InterestAccount, error) {
+	rows, err := DB.Query(context.Background(), `
+		SELECT id, COALESCE(interest_product_id, 0), COALESCE(interest_since, CURRENT_DATE),
+			(SELECT MAX(accrual_date) FROM interest_accruals WHERE account_id = accounts.id)
+		FROM accounts
+		WHERE interest_product_id IS NOT NULL
+			OR EXISTS (SELECT 1 FROM interest_accruals WHERE account_id = accounts.id AND transaction_id IS NULL)
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []InterestAccount
+	for rows.Next() {
+		var a InterestAccount
+		if err := rows.Scan(&a.AccountID, &a.ProductID, &a.Since, &a.AccruedThrough); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// EndOfDayBalance returns an account's balance at the end of day, the
+// balance after its last transaction that day or before
+func EndOfDayBalance(accountID int, day time.Time) (models.Money, error) {
+	var balance models.Money
+	err := DB.QueryRow(context.Background(),
+		`SELECT COALESCE((SELECT balance_after FROM transactions
+			WHERE account_id = $1 AND balance_after IS NOT NULL AND created_at < $2
+			ORDER BY id DESC LIMIT 1), 0)`,
+		accountID, day.UTC().AddDate(0, 0, 1)).Scan(&balance)
+	return balance, err
+}
+
+// AddAccrual records one day's interest, ignoring a day already accrued
+func AddAccrual(a models.InterestAccrual) error {
+	_, err := DB.Exec(context.Background(),
+		"INSERT INTO interest_accruals (account_id, accrual_date, product_id, balance, amount) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING",
+		a.AccountID, a.Date, a.ProductID, a.Balance, a.Amount)
+	return err
+}
+
+// ListAccruals returns an account's accruals, newest first
+func ListAccruals(accountID int) ([]models.InterestAccrual, error) {
+	rows, err := DB.Query(context.Background(),
+		"SELECT account_id, product_id, accrual_date, balance, amount, transaction_id FROM interest_accruals WHERE account_id = $1 ORDER BY accrual_date DESC",
+		accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accruals := []models.InterestAccrual{}
+	for rows.Next() {
+		var a models.InterestAccrual
+		if err := rows.Scan(&a.AccountID, &a.ProductID, &a.Date, &a.Balance, &a.Amount, &a.TransactionID); err != nil {
+			return nil, err
+		}
+		accruals = append(accruals, a)
+	}
+	return accruals, rows.Err()
+}
+
+// PostInterest pays an account the unposted interest it accrued up to and
+// including through, rounded to the nearest cent, from the interest system
+// account and records an interest transaction. The accruals are locked so
+// they are posted once. It returns nil when less than half a cent is due;
+// those accruals are carried into the next posting.
+func PostInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	return postAccruedNow(accountID, through, interestAccruals)
+}
+
+// accrualKind describes a table of daily accruals and how posting them
+// moves money between the account and the interest system account
+type accrualKind struct {
+	table       string
+	txType      string
+	description string
+	sign        models.Money // +1 credits the account, -1 debits it
+}
+
+var (
+	interestAccruals  = accrualKind{"interest_accruals", "interest", "interest", 1}
+	overdraftAccruals = accrualKind{"overdraft_accruals", "overdraft_interest", "overdraft interest", -1}
+)
+
+// postAccruedNow posts an account's accruals of kind up to through in a
+// transaction of its own
+func postAccruedNow(accountID int, through time.Time, kind accrualKind) (*models.Transaction, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	if _, err := lockAccount(ctx, tx, accountID); err != nil {
+		return nil, err
+	}
+	t, err := postAccrued(ctx, tx, accountID, through, kind)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	return t, tx.Commit(ctx)
+}
+
+// postAccrued posts the unposted accruals of kind of a locked account up to
+// and including through, rounded to the nearest unit of its currency, and
+// links them to the transaction recording it. It returns nil when less than
+// half a unit is due.
+func postAccrued(ctx context.Context, tx pgx.Tx, accountID int, through time.Time, kind accrualKind) (*models.Transaction, error) {
+	var accrued int64
+	err := tx.QueryRow(ctx,
+		`SELECT COALESCE(SUM(amount), 0)::bigint FROM (SELECT amount FROM `+kind.table+`
+			WHERE account_id = $1 AND accrual_date <= $2 AND transaction_id IS NULL FOR UPDATE) due`,
+		accountID, through.UTC()).Scan(&accrued)
+	if err != nil {
+		return nil, err
+	}
+	code, err := accountCurrency(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	amount := roundAccrued(code, accrued)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	interestID, err := systemAccountID(ctx, tx, InterestPaidAccount, accountID)
+	if err != nil {
+		return nil, err
+	}
+	entryID, balances, err := postEntry(ctx, tx, kind.description,
+		posting{accountID: interestID, amount: -kind.sign * amount},
+		posting{accountID: accountID, amount: kind.sign * amount})
+	if err != nil {
+		return nil, err
+	}
+
+	balanceAfter := balances[accountID]
+	t := models.Transaction{AccountID: accountID, Amount: amount, Type: kind.txType, BalanceAfter: &balanceAfter}
+	err = tx.QueryRow(ctx,
+		"INSERT INTO transactions (account_id, currency, amount, type, entry_id, balance_after) VALUES ($1, (SELECT currency FROM accounts WHERE id = $1), $2, $3, $4, $5) RETURNING id, currency, created_at",
+		accountID, amount, t.Type, entryID, balanceAfter).Scan(&t.ID, &t.Currency, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE "+kind.table+" SET transaction_id = $1 WHERE account_id = $2 AND accrual_date <= $3 AND transaction_id IS NULL",
+		t.ID, accountID, through.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// roundAccrued rounds accrual units to the nearest unit of currency code,
+// such as a cent or a yen
+func roundAccrued(code string, units int64) models.Money {
+	unit := currency.Unit(code)
+	perUnit := models.AccrualUnitsPerCent * int64(unit)
+	return models.Money((units+perUnit/2)/perUnit) * unit
+}
+
+const interestProductColumns = "id, name, annual_rate_bps, day_count, compounding, tiers, created_at"
+
+func scanInterestProduct(row pgx.Row) (*models.InterestProduct, error) {
+	var p models.InterestProduct
+	err := row.Scan(&p.ID, &p.Name, &p.AnnualRateBps, &p.DayCount, &p.Compounding, &p.Tiers, &p.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &p, nil
+}
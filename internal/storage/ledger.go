@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// System accounts balance entries against money entering or leaving the
+// bank. Deposits are funded from CashInAccount, withdrawals are paid out to
+// CashOutAccount, interest is paid from InterestPaidAccount, which also
+// receives overdraft interest, and fees are paid to FeesAccount, so their
+// balances run opposite to customer accounts. Each is kept once per
+// currency, named with the currency code appended, such as
+// system:cash_in:USD.
+const (
+	CashInAccount       = "system:cash_in"
+	CashOutAccount      = "system:cash_out"
+	InterestPaidAccount = "system:interest"
+	FeesAccount         = "system:fees"
+)
+
+var errUnbalancedEntry = errors.New("journal entry does not balance")
+
+// posting is one leg of a journal entry. Positive amounts increase the
+// account balance and negative amounts decrease it.
+type posting struct {
+	accountID int
+	amount    models.Money
+}
+
+// postEntry writes a balanced journal entry and applies its postings to the
+// account balances inside tx, returning the entry ID and each account's
+// balance after the entry. Postings are applied in account id order so that
+// concurrent entries touching the same accounts lock rows consistently.
+// The schema re-checks that the entry sums to zero when tx commits.
+func postEntry(ctx context.Context, tx pgx.Tx, description string, postings ...posting) (int, map[int]models.Money, error) {
+	var sum models.Money
+	for _, p := range postings {
+		sum += p.amount
+	}
+	if len(postings) < 2 || sum != 0 {
+		return 0, nil, errUnbalancedEntry
+	}
+
+	var entryID int
+	err := tx.QueryRow(ctx, "INSERT INTO journal_entries (description) VALUES ($1) RETURNING id", description).Scan(&entryID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ordered := slices.Clone(postings)
+	slices.SortFunc(ordered, func(a, b posting) int { return cmp.Compare(a.accountID, b.accountID) })
+
+	balances := make(map[int]models.Money, len(ordered))
+	for _, p := range ordered {
+		_, err = tx.Exec(ctx, "INSERT INTO postings (entry_id, account_id, amount) VALUES ($1, $2, $3)", entryID, p.accountID, p.amount)
+		if err != nil {
+			return 0, nil, err
+		}
+		var balance models.Money
+		err = tx.QueryRow(ctx, "UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance", p.amount, p.accountID).Scan(&balance)
+		if err != nil {
+			return 0, nil, fmt.Errorf("account %d: %w", p.accountID, notFound(err))
+		}
+		balances[p.accountID] = balance
+	}
+
+	return entryID, balances, nil
+}
+
+// systemAccountID looks up the system account name keeps in the currency of
+// account accountID, creating it when that currency is first used
+func systemAccountID(ctx context.Context, tx pgx.Tx, name string, accountID int) (int, error) {
+	code, err := accountCurrency(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	name += ":" + code
+
+	var id int
+	err = tx.QueryRow(ctx, "SELECT id FROM accounts WHERE name = $1 AND is_system", name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// A concurrent first use may create it too; read it back either way
+		_, err = tx.Exec(ctx, "INSERT INTO accounts (name, is_system, currency) VALUES ($1, TRUE, $2) ON CONFLICT (name) WHERE is_system DO NOTHING", name, code)
+		if err != nil {
+			return 0, fmt.Errorf("system account %s: %w", name, err)
+		}
+		err = tx.QueryRow(ctx, "SELECT id FROM accounts WHERE name = $1 AND is_system", name).Scan(&id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("system account %s: %w", name, err)
+	}
+	return id, nil
+}
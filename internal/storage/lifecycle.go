@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/lifecycle"
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrAccountNotActive is returned when an account's status does not allow a
+// transaction, such as a debit from a frozen account
+var ErrAccountNotActive = errors.New("account status does not allow this transaction")
+
+// ErrInvalidTransition is returned when an action does not apply to an
+// account's current status, such as unfreezing an account that is not frozen
+var ErrInvalidTransition = errors.New("account status does not allow this change")
+
+// ErrBalanceNotZero is returned when closing an account that owes money, or
+// holds money that is not to be paid out
+var ErrBalanceNotZero = errors.New("account balance must be zero to close")
+
+// customerActivity lists the transaction types that keep an account from
+// going dormant
+const customerActivity = "('account_creation', 'deposit', 'withdraw', 'transfer_in', 'transfer_out', 'capture')"
+
+// ChangeAccountStatus applies an administrator's action other than closing
+// to an account and records the change with its reason and actor
+func ChangeAccountStatus(id int, action, reason, actor string) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	status, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	to, err := nextStatus(action, status)
+	if err != nil {
+		return err
+	}
+	if err := setStatus(ctx, tx, id, status, to, reason, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CloseAccount closes an account for good. Interest and overdraft interest
+// accrued so far are posted first. A positive balance is then paid out to
+// the cash-out system account when payout is set; otherwise, or when the
+// account is overdrawn, the balance must be zero. Active holds are released,
+// the account's schedules cancelled and its interest product and overdraft
+// removed.
+func CloseAccount(id int, reason, actor string, payout bool) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	status, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	to, err := nextStatus(lifecycle.Close, status)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := postAccrued(ctx, tx, id, now, interestAccruals); err != nil {
+		return err
+	}
+	if _, err := postAccrued(ctx, tx, id, now, overdraftAccruals); err != nil {
+		return err
+	}
+
+	balance, err := lockAccount(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if balance < 0 || balance > 0 && !payout {
+		return ErrBalanceNotZero
+	}
+	if balance > 0 {
+		cashOut, err := systemAccountID(ctx, tx, CashOutAccount, id)
+		if err != nil {
+			return err
+		}
+		entryID, balances, err := postEntry(ctx, tx, "closing payout",
+			posting{accountID: id, amount: -balance},
+			posting{accountID: cashOut, amount: balance})
+		if err != nil {
+			return err
+		}
+		balanceAfter := balances[id]
+		if err := addTransaction(ctx, tx, id, balance, "payout", entryID, &balanceAfter); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE holds SET status = 'released', updated_at = CURRENT_TIMESTAMP WHERE account_id = $1 AND status = 'active'", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE schedules SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE (account_id = $1 OR to_account_id = $1) AND status IN ('active', 'paused')`, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE accounts SET interest_product_id = NULL, interest_since = NULL,
+			overdraft_limit = 0, overdraft_rate_bps = 0, overdraft_since = NULL
+		WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if err := setStatus(ctx, tx, id, status, to, reason, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListAccountEvents returns an account's status changes, oldest first
+func ListAccountEvents(id int) ([]models.AccountEvent, error) {
+	rows, err := DB.Query(context.Background(),
+		"SELECT id, account_id, from_status, to_status, reason, actor, created_at FROM account_events WHERE account_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AccountEvent{}
+	for rows.Next() {
+		var e models.AccountEvent
+		if err := rows.Scan(&e.ID, &e.AccountID, &e.FromStatus, &e.ToStatus, &e.Reason, &e.Actor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// MarkDormant moves active accounts without a customer transaction since
+// inactiveSince to dormant and returns how many it moved
+func MarkDormant(inactiveSince time.Time) (int, error) {
+	tag, err := DB.Exec(context.Background(), `
+		WITH dormant AS (
+			UPDATE accounts SET status = 'dormant'
+			WHERE status = 'active' AND NOT is_system AND NOT EXISTS (
+				SELECT 1 FROM transactions
+				WHERE account_id = accounts.id AND type IN `+customerActivity+` AND created_at >= $1)
+			RETURNING id
+		)
+		INSERT INTO account_events (account_id, from_status, to_status, reason, actor)
+		SELECT id, 'active', 'dormant', 'no customer activity', 'system' FROM dormant`,
+		inactiveSince.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// requireStatus fails with ErrAccountNotActive unless a customer account's
+// status allows a debit, or a credit when debit is false
+func requireStatus(ctx context.Context, tx pgx.Tx, id int, debit bool) error {
+	status, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	return checkStatus(id, status, debit)
+}
+
+// checkStatus fails with ErrAccountNotActive unless status allows a debit,
+// or a credit when debit is false
+func checkStatus(id int, status string, debit bool) error {
+	if debit && lifecycle.AllowsDebit(status) || !debit && lifecycle.AllowsCredit(status) {
+		return nil
+	}
+	return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, id, status)
+}
+
+// nextStatus returns the status action leads to from status
+func nextStatus(action, status string) (string, error) {
+	to, ok := lifecycle.Next(action, status)
+	if !ok {
+		return "", fmt.Errorf("%w: cannot %s a %s account", ErrInvalidTransition, action, status)
+	}
+	return to, nil
+}
+
+// lockStatus locks a customer account like lockAccount and returns its status
+func lockStatus(ctx context.Context, tx pgx.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRow(ctx, "SELECT status FROM accounts WHERE id = $1 AND NOT is_system FOR UPDATE", id).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("lock account %d: %w", id, notFound(err))
+	}
+	return status, nil
+}
+
+// setStatus moves a locked account from status from to to and records why
+func setStatus(ctx context.Context, tx pgx.Tx, id int, from, to, reason, actor string) error {
+	if _, err := tx.Exec(ctx, "UPDATE accounts SET status = $1 WHERE id = $2", to, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		"INSERT INTO account_events (account_id, from_status, to_status, reason, actor) VALUES ($1, $2, $3, $4, $5)",
+		id, from, to, reason, actor)
+	return err
+}
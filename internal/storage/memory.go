@@ -0,0 +1,1268 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/fees"
+	"banking-ledger-service/internal/lifecycle"
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrAccountExists is returned when an account name is already taken
+var ErrAccountExists = errors.New("account already exists")
+
+// Memory implements the repositories and the queue publisher in process
+// memory. It follows the same rules as the Postgres backend, including
+// idempotency keys and overdraft limits, but keeps nothing across
+// restarts. It is meant for demos and end-to-end tests.
+type Memory struct {
+	mu              sync.Mutex
+	accounts        map[int]*models.Account
+	customers       map[int]*models.Customer
+	transactions    []models.Transaction
+	operations      map[int]*models.Operation
+	operationKeys   map[string]int
+	idempotencyKeys map[string]int
+	auditLog        []models.Transaction
+	holds           map[int]*models.Hold
+	schedules       map[int]*models.Schedule
+	products        map[int]*models.InterestProduct
+	interestSince   map[int]time.Time
+	accruals        []models.InterestAccrual
+	overdraftSince  map[int]time.Time
+	overdrafts      []models.OverdraftAccrual
+	accountEvents   []models.AccountEvent
+	feeRules        []models.FeeRule
+	nextAccountID   int
+	nextCustomerID  int
+	nextOperationID int
+
+	// publish hands a queued message to the worker
+	publish func(message string) error
+}
+
+// NewMemory creates an empty in-memory store that queues messages with publish
+func NewMemory(publish func(message string) error) *Memory {
+	return &Memory{
+		accounts:        map[int]*models.Account{},
+		customers:       map[int]*models.Customer{},
+		operations:      map[int]*models.Operation{},
+		operationKeys:   map[string]int{},
+		idempotencyKeys: map[string]int{},
+		holds:           map[int]*models.Hold{},
+		schedules:       map[int]*models.Schedule{},
+		products:        map[int]*models.InterestProduct{},
+		interestSince:   map[int]time.Time{},
+		overdraftSince:  map[int]time.Time{},
+		publish:         publish,
+	}
+}
+
+func (m *Memory) CreateAccount(customerID *int, name, productCode, currencyCode string, balance models.Money, idempotencyKey string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		return existing, ErrDuplicateRequest
+	}
+	if balance < 0 {
+		return 0, ErrInsufficientFunds
+	}
+	product, err := openingProduct(productCode, balance)
+	if err != nil {
+		return 0, err
+	}
+	currencyCode, err = openingCurrency(product, currencyCode, balance)
+	if err != nil {
+		return 0, err
+	}
+	if customerID == nil {
+		c := m.addCustomer(models.Customer{LegalName: name})
+		customerID = &c.ID
+	} else if _, ok := m.customers[*customerID]; !ok {
+		return 0, fmt.Errorf("customer %d: %w", *customerID, ErrNotFound)
+	}
+	for _, acc := range m.accounts {
+		if *acc.CustomerID == *customerID && acc.Name == name {
+			return 0, ErrAccountExists
+		}
+	}
+
+	m.nextAccountID++
+	id := m.nextAccountID
+	owner := *customerID
+	m.accounts[id] = &models.Account{ID: id, Name: name, CustomerID: &owner, ProductCode: product.Code, Currency: currencyCode, Status: models.AccountActive, Balance: balance, AccountType: models.DefaultAccountType}
+	m.claim(idempotencyKey, id)
+	m.addTransaction(id, balance, "account_creation")
+	return id, nil
+}
+
+func (m *Memory) GetAccount(id int) (*models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *acc
+	copied.AvailableBalance = m.available(id)
+	return &copied, nil
+}
+
+func (m *Memory) UpdateBalance(accountID int, amount models.Money, operation string, idempotencyKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		return ErrDuplicateRequest
+	}
+	acc, ok := m.accounts[accountID]
+	if !ok {
+		return fmt.Errorf("lock account %d: %w", accountID, ErrNotFound)
+	}
+	if err := checkStatus(accountID, acc.Status, operation == "withdraw"); err != nil {
+		return err
+	}
+	if err := amountRule(acc.Currency, amount); err != nil {
+		return err
+	}
+
+	// Fees are charged along with the transaction and must be covered too
+	charges := m.dueFees(accountID, operation, amount)
+	due := fees.Total(charges)
+	available := m.available(accountID)
+
+	switch operation {
+	case "deposit":
+		if due > 0 && available+amount < due {
+			return ErrInsufficientFunds
+		}
+	case "withdraw":
+		if available < amount+due {
+			return ErrInsufficientFunds
+		}
+	default:
+		return fmt.Errorf("unsupported balance operation %q", operation)
+	}
+	if err := m.checkProduct(accountID, operation, amount, available-due); err != nil {
+		return err
+	}
+	if operation == "deposit" {
+		acc.Balance += amount
+	} else {
+		acc.Balance -= amount
+	}
+
+	m.claim(idempotencyKey, accountID)
+	m.addTransaction(accountID, amount, operation)
+	transactionID := len(m.transactions)
+	for _, c := range charges {
+		acc.Balance -= c.Amount
+		m.addTransaction(accountID, c.Amount, "fee")
+		fee := &m.transactions[len(m.transactions)-1]
+		fee.FeeFor = &transactionID
+		fee.Reason = c.Name
+	}
+	return nil
+}
+
+// dueFees evaluates the fee rules for a transaction on an account, charging
+// none while its status blocks debits; m.mu must be held
+func (m *Memory) dueFees(accountID int, txType string, amount models.Money) []models.FeeCharge {
+	if !lifecycle.AllowsDebit(m.accounts[accountID].Status) {
+		return []models.FeeCharge{}
+	}
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthCount := 0
+	for _, t := range m.transactions {
+		if t.AccountID == accountID && t.Type == txType && !t.CreatedAt.Before(monthStart) {
+			monthCount++
+		}
+	}
+	acc := m.accounts[accountID]
+	return roundCharges(acc.Currency, fees.Evaluate(m.feeRules, txType, acc.AccountType, amount, monthCount))
+}
+
+// checkProduct applies the rules of the account's product to a transaction
+// like the Postgres checkProduct, leaving the overdraft limit out of the
+// minimum balance; m.mu must be held
+func (m *Memory) checkProduct(accountID int, txType string, amount, available models.Money) error {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	withdrawals := 0
+	for _, t := range m.transactions {
+		if t.AccountID == accountID && (t.Type == "withdraw" || t.Type == "transfer_out") && !t.CreatedAt.Before(monthStart) {
+			withdrawals++
+		}
+	}
+	acc := m.accounts[accountID]
+	return productRule(acc.ProductCode, txType, amount, available-acc.OverdraftLimit, withdrawals)
+}
+
+func (m *Memory) SetAccountType(id int, accountType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	acc.AccountType = accountType
+	return nil
+}
+
+func (m *Memory) SetOverdraft(id int, limit models.Money, rateBps *int, since time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	acc.OverdraftLimit = limit
+	if rateBps != nil {
+		acc.OverdraftRateBps = *rateBps
+	}
+	if acc.OverdraftRateBps == 0 {
+		delete(m.overdraftSince, id)
+	} else if _, ok := m.overdraftSince[id]; !ok {
+		y, mo, d := since.UTC().Date()
+		m.overdraftSince[id] = time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+	}
+	return nil
+}
+
+func (m *Memory) ChangeAccountStatus(id int, action, reason, actor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok {
+		return fmt.Errorf("lock account %d: %w", id, ErrNotFound)
+	}
+	to, err := nextStatus(action, acc.Status)
+	if err != nil {
+		return err
+	}
+	m.setStatus(acc, to, reason, actor)
+	return nil
+}
+
+func (m *Memory) CloseAccount(id int, reason, actor string, payout bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok {
+		return fmt.Errorf("lock account %d: %w", id, ErrNotFound)
+	}
+	to, err := nextStatus(lifecycle.Close, acc.Status)
+	if err != nil {
+		return err
+	}
+
+	// Settle accrued interest first, leaving the balance as it was if the
+	// account cannot be closed after all
+	var earned, owed int64
+	for _, a := range m.accruals {
+		if a.AccountID == id && a.TransactionID == nil {
+			earned += a.Amount
+		}
+	}
+	for _, a := range m.overdrafts {
+		if a.AccountID == id && a.TransactionID == nil {
+			owed += a.Amount
+		}
+	}
+	balance := acc.Balance + roundAccrued(acc.Currency, earned) - roundAccrued(acc.Currency, owed)
+	if balance < 0 || balance > 0 && !payout {
+		return ErrBalanceNotZero
+	}
+
+	now := time.Now()
+	m.postInterest(id, now)
+	m.chargeOverdraftInterest(id, now)
+	if acc.Balance > 0 {
+		amount := acc.Balance
+		acc.Balance = 0
+		m.addTransaction(id, amount, "payout")
+	}
+
+	for _, h := range m.holds {
+		if h.AccountID == id && h.Status == models.HoldActive {
+			h.Status = models.HoldReleased
+			h.UpdatedAt = now
+		}
+	}
+	for _, sched := range m.schedules {
+		if (sched.AccountID == id || sched.ToAccountID != nil && *sched.ToAccountID == id) &&
+			(sched.Status == models.ScheduleActive || sched.Status == models.SchedulePaused) {
+			sched.Status = models.ScheduleCancelled
+			sched.UpdatedAt = now
+		}
+	}
+	acc.InterestProductID = nil
+	delete(m.interestSince, id)
+	acc.OverdraftLimit, acc.OverdraftRateBps = 0, 0
+	delete(m.overdraftSince, id)
+	m.setStatus(acc, to, reason, actor)
+	return nil
+}
+
+func (m *Memory) ListAccountEvents(id int) ([]models.AccountEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := []models.AccountEvent{}
+	for _, e := range m.accountEvents {
+		if e.AccountID == id {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *Memory) MarkDormant(inactiveSince time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := map[int]bool{}
+	for _, t := range m.transactions {
+		switch t.Type {
+		case "account_creation", "deposit", "withdraw", "transfer_in", "transfer_out", "capture":
+			if !t.CreatedAt.Before(inactiveSince) {
+				active[t.AccountID] = true
+			}
+		}
+	}
+
+	marked := 0
+	for id := 1; id <= m.nextAccountID; id++ {
+		acc, ok := m.accounts[id]
+		if ok && acc.Status == models.AccountActive && !active[id] {
+			m.setStatus(acc, models.AccountDormant, "no customer activity", "system")
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// setStatus moves an account to status to and records why; m.mu must be held
+func (m *Memory) setStatus(acc *models.Account, to, reason, actor string) {
+	m.accountEvents = append(m.accountEvents, models.AccountEvent{
+		ID:         len(m.accountEvents) + 1,
+		AccountID:  acc.ID,
+		FromStatus: acc.Status,
+		ToStatus:   to,
+		Reason:     reason,
+		Actor:      actor,
+		CreatedAt:  time.Now(),
+	})
+	acc.Status = to
+}
+
+func (m *Memory) CreateCustomer(c models.Customer) (*models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addCustomer(c), nil
+}
+
+func (m *Memory) GetCustomer(id int) (*models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.customers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (m *Memory) ListCustomers() ([]models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	customers := []models.Customer{}
+	for _, c := range m.customers {
+		customers = append(customers, *c)
+	}
+	sort.Slice(customers, func(i, j int) bool { return customers[i].ID < customers[j].ID })
+	return customers, nil
+}
+
+func (m *Memory) UpdateCustomer(c models.Customer) (*models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.customers[c.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c.CreatedAt = existing.CreatedAt
+	c.UpdatedAt = time.Now()
+	*existing = c
+	return &c, nil
+}
+
+func (m *Memory) DeleteCustomer(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.customers[id]; !ok {
+		return ErrNotFound
+	}
+	for _, acc := range m.accounts {
+		if *acc.CustomerID == id {
+			return ErrCustomerHasAccounts
+		}
+	}
+	delete(m.customers, id)
+	return nil
+}
+
+func (m *Memory) ListCustomerAccounts(customerID int) ([]models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.customers[customerID]; !ok {
+		return nil, ErrNotFound
+	}
+	accounts := []models.Account{}
+	for _, acc := range m.accounts {
+		if *acc.CustomerID == customerID {
+			copied := *acc
+			copied.AvailableBalance = m.available(acc.ID)
+			accounts = append(accounts, copied)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts, nil
+}
+
+// addCustomer stores a new customer; m.mu must be held
+func (m *Memory) addCustomer(c models.Customer) *models.Customer {
+	m.nextCustomerID++
+	now := time.Now()
+	c.ID = m.nextCustomerID
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	m.customers[c.ID] = &c
+	copied := c
+	return &copied
+}
+
+func (m *Memory) CreateFeeRule(r models.FeeRule) (*models.FeeRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.ID = len(m.feeRules) + 1
+	r.Active = true
+	r.CreatedAt = time.Now()
+	m.feeRules = append(m.feeRules, r)
+	return &r, nil
+}
+
+func (m *Memory) ListFeeRules() ([]models.FeeRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.FeeRule{}, m.feeRules...), nil
+}
+
+func (m *Memory) DeactivateFeeRule(id int) (*models.FeeRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id < 1 || id > len(m.feeRules) {
+		return nil, ErrNotFound
+	}
+	m.feeRules[id-1].Active = false
+	r := m.feeRules[id-1]
+	return &r, nil
+}
+
+func (m *Memory) PreviewFees(accountID int, txType string, amount models.Money) ([]models.FeeCharge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[accountID]; !ok {
+		return nil, fmt.Errorf("account %d: %w", accountID, ErrNotFound)
+	}
+	return m.dueFees(accountID, txType, amount), nil
+}
+
+func (m *Memory) Transfer(fromID, toID int, amount models.Money, idempotencyKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		return ErrDuplicateRequest
+	}
+	from, ok := m.accounts[fromID]
+	if !ok {
+		return fmt.Errorf("lock account %d: %w", fromID, ErrNotFound)
+	}
+	to, ok := m.accounts[toID]
+	if !ok {
+		return fmt.Errorf("lock account %d: %w", toID, ErrNotFound)
+	}
+	if err := checkStatus(fromID, from.Status, true); err != nil {
+		return err
+	}
+	if err := checkStatus(toID, to.Status, false); err != nil {
+		return err
+	}
+	if from.Currency != to.Currency {
+		return fmt.Errorf("%w: cannot transfer %s to a %s account", ErrCurrencyMismatch, from.Currency, to.Currency)
+	}
+	if err := amountRule(from.Currency, amount); err != nil {
+		return err
+	}
+	available := m.available(fromID)
+	if available < amount {
+		return ErrInsufficientFunds
+	}
+	if err := m.checkProduct(fromID, "transfer_out", amount, available); err != nil {
+		return err
+	}
+	if err := m.checkProduct(toID, "transfer_in", amount, 0); err != nil {
+		return err
+	}
+
+	from.Balance -= amount
+	to.Balance += amount
+	m.claim(idempotencyKey, fromID)
+	m.addTransaction(fromID, amount, "transfer_out")
+	m.addTransaction(toID, amount, "transfer_in")
+	return nil
+}
+
+// available is an account's balance less its active holds plus its
+// overdraft limit; m.mu must be held
+func (m *Memory) available(accountID int) models.Money {
+	available := m.accounts[accountID].Balance + m.accounts[accountID].OverdraftLimit
+	for _, h := range m.holds {
+		if h.AccountID == accountID && holdStatus(h) == models.HoldActive {
+			available -= h.Amount
+		}
+	}
+	return available
+}
+
+// holdStatus reports an active hold past its expiry as expired
+func holdStatus(h *models.Hold) string {
+	if h.Status == models.HoldActive && !h.ExpiresAt.After(time.Now()) {
+		return models.HoldExpired
+	}
+	return h.Status
+}
+
+func (m *Memory) PlaceHold(accountID int, amount models.Money, reference string, expiresAt time.Time) (*models.Hold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("lock account %d: %w", accountID, ErrNotFound)
+	}
+	if err := checkStatus(accountID, acc.Status, true); err != nil {
+		return nil, err
+	}
+	if err := amountRule(acc.Currency, amount); err != nil {
+		return nil, err
+	}
+	available := m.available(accountID)
+	if available < amount {
+		return nil, ErrInsufficientFunds
+	}
+	if err := m.checkProduct(accountID, "capture", amount, available); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	h := &models.Hold{
+		ID:        len(m.holds) + 1,
+		AccountID: accountID,
+		Amount:    amount,
+		Reference: reference,
+		Status:    models.HoldActive,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.holds[h.ID] = h
+	return copyHold(h), nil
+}
+
+func (m *Memory) GetHold(id int) (*models.Hold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.holds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyHold(h), nil
+}
+
+func (m *Memory) CaptureHold(id int, amount models.Money) (*models.Hold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.activeHold(id)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+	if err := checkStatus(h.AccountID, m.accounts[h.AccountID].Status, true); err != nil {
+		return nil, err
+	}
+	if err := amountRule(m.accounts[h.AccountID].Currency, amount); err != nil {
+		return nil, err
+	}
+
+	m.accounts[h.AccountID].Balance -= amount
+	h.Status = models.HoldCaptured
+	h.CapturedAmount = amount
+	h.UpdatedAt = time.Now()
+	m.addTransaction(h.AccountID, amount, "capture")
+	return copyHold(h), nil
+}
+
+func (m *Memory) ReleaseHold(id int) (*models.Hold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.activeHold(id)
+	if err != nil {
+		return nil, err
+	}
+	h.Status = models.HoldReleased
+	h.UpdatedAt = time.Now()
+	return copyHold(h), nil
+}
+
+// activeHold looks up a hold that can still be captured or released; m.mu
+// must be held
+func (m *Memory) activeHold(id int) (*models.Hold, error) {
+	h, ok := m.holds[id]
+	if !ok {
+		return nil, fmt.Errorf("lock hold %d: %w", id, ErrNotFound)
+	}
+	if holdStatus(h) != models.HoldActive {
+		return nil, ErrHoldNotActive
+	}
+	return h, nil
+}
+
+func copyHold(h *models.Hold) *models.Hold {
+	copied := *h
+	copied.Status = holdStatus(h)
+	return &copied
+}
+
+func (m *Memory) CreateSchedule(s models.Schedule) (*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	s.ID = len(m.schedules) + 1
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	m.schedules[s.ID] = &s
+	copied := s
+	return &copied, nil
+}
+
+func (m *Memory) GetSchedule(id int) (*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (m *Memory) ListSchedules(accountID int) ([]models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.filterSchedules(func(s *models.Schedule) bool {
+		return s.AccountID == accountID || s.ToAccountID != nil && *s.ToAccountID == accountID
+	}), nil
+}
+
+func (m *Memory) SetScheduleStatus(id int, status string, nextRunAt *time.Time) (*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.Status != models.ScheduleActive && s.Status != models.SchedulePaused {
+		return nil, ErrScheduleClosed
+	}
+	s.Status = status
+	if nextRunAt != nil {
+		s.NextRunAt = nextRunAt
+	}
+	s.UpdatedAt = time.Now()
+	copied := *s
+	return &copied, nil
+}
+
+func (m *Memory) DueSchedules(now time.Time, limit int) ([]models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := m.filterSchedules(func(s *models.Schedule) bool {
+		return s.Status == models.ScheduleActive && s.NextRunAt != nil && !s.NextRunAt.After(now)
+	})
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(*due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (m *Memory) AdvanceSchedule(id int, from time.Time, next *time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok || s.Status != models.ScheduleActive || s.NextRunAt == nil || !s.NextRunAt.Equal(from) {
+		return false, nil
+	}
+	now := time.Now()
+	s.NextRunAt = next
+	s.LastRunAt = &now
+	s.UpdatedAt = now
+	if next == nil {
+		s.Status = models.ScheduleCompleted
+	}
+	return true, nil
+}
+
+// filterSchedules returns copies of the matching schedules ordered by ID;
+// m.mu must be held
+func (m *Memory) filterSchedules(match func(*models.Schedule) bool) []models.Schedule {
+	schedules := []models.Schedule{}
+	for _, s := range m.schedules {
+		if match(s) {
+			schedules = append(schedules, *s)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+	return schedules
+}
+
+func (m *Memory) CreateInterestProduct(p models.InterestProduct) (*models.InterestProduct, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.products {
+		if existing.Name == p.Name {
+			return nil, ErrProductExists
+		}
+	}
+	if p.Tiers == nil {
+		p.Tiers = []models.InterestTier{}
+	}
+	p.ID = len(m.products) + 1
+	p.CreatedAt = time.Now()
+	m.products[p.ID] = &p
+	copied := p
+	return &copied, nil
+}
+
+func (m *Memory) GetInterestProduct(id int) (*models.InterestProduct, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *p
+	return &copied, nil
+}
+
+func (m *Memory) ListInterestProducts() ([]models.InterestProduct, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	products := []models.InterestProduct{}
+	for id := 1; id <= len(m.products); id++ {
+		products = append(products, *m.products[id])
+	}
+	return products, nil
+}
+
+func (m *Memory) SetInterestProduct(accountID int, productID *int, since time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[accountID]
+	if !ok {
+		return ErrNotFound
+	}
+	acc.InterestProductID = productID
+	if productID == nil {
+		delete(m.interestSince, accountID)
+	} else {
+		y, mo, d := since.UTC().Date()
+		m.interestSince[accountID] = time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+	}
+	return nil
+}
+
+func (m *Memory) InterestAccounts() ([]InterestAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accrued := map[int]time.Time{}
+	unposted := map[int]bool{}
+	for _, a := range m.accruals {
+		if a.Date.After(accrued[a.AccountID]) {
+			accrued[a.AccountID] = a.Date
+		}
+		if a.TransactionID == nil {
+			unposted[a.AccountID] = true
+		}
+	}
+
+	var accounts []InterestAccount
+	for id := 1; id <= m.nextAccountID; id++ {
+		acc, ok := m.accounts[id]
+		if !ok || acc.InterestProductID == nil && !unposted[id] {
+			continue
+		}
+		a := InterestAccount{AccountID: id, Since: m.interestSince[id]}
+		if acc.InterestProductID != nil {
+			a.ProductID = *acc.InterestProductID
+		}
+		if through, ok := accrued[id]; ok {
+			a.AccruedThrough = &through
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+func (m *Memory) EndOfDayBalance(accountID int, day time.Time) (models.Money, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	end := day.UTC().AddDate(0, 0, 1)
+	for i := len(m.transactions) - 1; i >= 0; i-- {
+		t := m.transactions[i]
+		if t.AccountID == accountID && t.BalanceAfter != nil && t.CreatedAt.Before(end) {
+			return *t.BalanceAfter, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *Memory) AddAccrual(a models.InterestAccrual) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.accruals {
+		if existing.AccountID == a.AccountID && existing.Date.Equal(a.Date) {
+			return nil
+		}
+	}
+	m.accruals = append(m.accruals, a)
+	return nil
+}
+
+func (m *Memory) ListAccruals(accountID int) ([]models.InterestAccrual, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accruals := []models.InterestAccrual{}
+	for i := len(m.accruals) - 1; i >= 0; i-- {
+		if m.accruals[i].AccountID == accountID {
+			accruals = append(accruals, m.accruals[i])
+		}
+	}
+	sort.SliceStable(accruals, func(i, j int) bool { return accruals[i].Date.After(accruals[j].Date) })
+	return accruals, nil
+}
+
+func (m *Memory) PostInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.postInterest(accountID, through)
+}
+
+// postInterest is PostInterest with m.mu held
+func (m *Memory) postInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	if _, ok := m.accounts[accountID]; !ok {
+		return nil, fmt.Errorf("lock account %d: %w", accountID, ErrNotFound)
+	}
+
+	var due []int
+	var accrued int64
+	for i, a := range m.accruals {
+		if a.AccountID == accountID && a.TransactionID == nil && !a.Date.After(through) {
+			due = append(due, i)
+			accrued += a.Amount
+		}
+	}
+	amount := roundAccrued(m.accounts[accountID].Currency, accrued)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	m.accounts[accountID].Balance += amount
+	m.addTransaction(accountID, amount, "interest")
+	t := m.transactions[len(m.transactions)-1]
+	for _, i := range due {
+		m.accruals[i].TransactionID = &t.ID
+	}
+	return &t, nil
+}
+
+func (m *Memory) OverdraftAccounts() ([]OverdraftAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accrued := map[int]time.Time{}
+	unposted := map[int]bool{}
+	for _, a := range m.overdrafts {
+		if a.Date.After(accrued[a.AccountID]) {
+			accrued[a.AccountID] = a.Date
+		}
+		if a.TransactionID == nil {
+			unposted[a.AccountID] = true
+		}
+	}
+
+	var accounts []OverdraftAccount
+	for id := 1; id <= m.nextAccountID; id++ {
+		acc, ok := m.accounts[id]
+		if !ok || acc.OverdraftRateBps == 0 && !unposted[id] {
+			continue
+		}
+		a := OverdraftAccount{AccountID: id, RateBps: acc.OverdraftRateBps, Since: m.overdraftSince[id]}
+		if through, ok := accrued[id]; ok {
+			a.AccruedThrough = &through
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+func (m *Memory) AddOverdraftAccrual(a models.OverdraftAccrual) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.overdrafts {
+		if existing.AccountID == a.AccountID && existing.Date.Equal(a.Date) {
+			return nil
+		}
+	}
+	m.overdrafts = append(m.overdrafts, a)
+	return nil
+}
+
+func (m *Memory) ListOverdraftAccruals(accountID int) ([]models.OverdraftAccrual, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accruals := []models.OverdraftAccrual{}
+	for _, a := range m.overdrafts {
+		if a.AccountID == accountID {
+			accruals = append(accruals, a)
+		}
+	}
+	sort.SliceStable(accruals, func(i, j int) bool { return accruals[i].Date.After(accruals[j].Date) })
+	return accruals, nil
+}
+
+func (m *Memory) ChargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.chargeOverdraftInterest(accountID, through)
+}
+
+// chargeOverdraftInterest is ChargeOverdraftInterest with m.mu held
+func (m *Memory) chargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	if _, ok := m.accounts[accountID]; !ok {
+		return nil, fmt.Errorf("lock account %d: %w", accountID, ErrNotFound)
+	}
+
+	var due []int
+	var accrued int64
+	for i, a := range m.overdrafts {
+		if a.AccountID == accountID && a.TransactionID == nil && !a.Date.After(through) {
+			due = append(due, i)
+			accrued += a.Amount
+		}
+	}
+	amount := roundAccrued(m.accounts[accountID].Currency, accrued)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	m.accounts[accountID].Balance -= amount
+	m.addTransaction(accountID, amount, "overdraft_interest")
+	t := m.transactions[len(m.transactions)-1]
+	for _, i := range due {
+		m.overdrafts[i].TransactionID = &t.ID
+	}
+	return &t, nil
+}
+
+// claim records an applied idempotency key; m.mu must be held
+func (m *Memory) claim(key string, accountID int) {
+	if key != "" {
+		m.idempotencyKeys[key] = accountID
+	}
+}
+
+// addTransaction appends a history row carrying the account's new balance;
+// m.mu must be held
+func (m *Memory) addTransaction(accountID int, amount models.Money, txType string) {
+	balanceAfter := m.accounts[accountID].Balance
+	m.transactions = append(m.transactions, models.Transaction{
+		ID:           len(m.transactions) + 1,
+		AccountID:    accountID,
+		Amount:       amount,
+		Currency:     m.accounts[accountID].Currency,
+		Type:         txType,
+		BalanceAfter: &balanceAfter,
+		CreatedAt:    time.Now(),
+	})
+}
+
+func (m *Memory) ListTransactions(q TransactionQuery) ([]models.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transactions := []models.Transaction{}
+	for _, t := range m.transactions {
+		switch {
+		case t.AccountID != q.AccountID,
+			q.Type != "" && t.Type != q.Type,
+			q.MinAmount != nil && t.Amount < *q.MinAmount,
+			q.MaxAmount != nil && t.Amount > *q.MaxAmount,
+			q.From != nil && t.CreatedAt.Before(*q.From),
+			q.To != nil && !t.CreatedAt.Before(*q.To),
+			q.AfterID != 0 && !q.Descending && t.ID <= q.AfterID,
+			q.AfterID != 0 && q.Descending && t.ID >= q.AfterID:
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+
+	if q.Descending {
+		sort.Slice(transactions, func(i, j int) bool { return transactions[i].ID > transactions[j].ID })
+	}
+	if len(transactions) > q.Limit {
+		transactions = transactions[:q.Limit]
+	}
+	return transactions, nil
+}
+
+func (m *Memory) GetTransaction(id int) (*models.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id < 1 || id > len(m.transactions) {
+		return nil, ErrNotFound
+	}
+	t := m.transactions[id-1]
+	return &t, nil
+}
+
+func (m *Memory) ReverseTransaction(transactionID int, reason, actor, idempotencyKey string) (*models.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		return nil, ErrDuplicateRequest
+	}
+	if transactionID < 1 || transactionID > len(m.transactions) {
+		return nil, fmt.Errorf("lock transaction %d: %w", transactionID, ErrNotFound)
+	}
+	original := &m.transactions[transactionID-1]
+	if original.ReversedBy != nil {
+		return nil, ErrAlreadyReversed
+	}
+
+	acc := m.accounts[original.AccountID]
+	if err := checkStatus(original.AccountID, acc.Status, false); err != nil {
+		return nil, err
+	}
+
+	// Fees charged with the original are refunded with it
+	var charged []int
+	var refund models.Money
+	for i, t := range m.transactions {
+		if t.FeeFor != nil && *t.FeeFor == transactionID && t.ReversedBy == nil {
+			charged = append(charged, i+1)
+			refund += t.Amount
+		}
+	}
+
+	switch original.Type {
+	case "deposit":
+		if m.available(original.AccountID)+refund < original.Amount {
+			return nil, ErrInsufficientFunds
+		}
+	case "withdraw":
+	default:
+		return nil, ErrNotReversible
+	}
+
+	m.claim(idempotencyKey, original.AccountID)
+	for _, feeID := range charged {
+		acc.Balance += m.transactions[feeID-1].Amount
+		m.addReversal(feeID, reason, actor)
+	}
+	if original.Type == "deposit" {
+		acc.Balance -= original.Amount
+	} else {
+		acc.Balance += original.Amount
+	}
+	reversal := m.addReversal(transactionID, reason, actor)
+	return &reversal, nil
+}
+
+// addReversal records the reversal of a transaction whose amount has been
+// moved back; m.mu must be held
+func (m *Memory) addReversal(transactionID int, reason, actor string) models.Transaction {
+	original := m.transactions[transactionID-1]
+	m.addTransaction(original.AccountID, original.Amount, "reversal")
+
+	// Appending may have moved the slice, so index it afresh
+	reversalID := len(m.transactions)
+	reversal := &m.transactions[reversalID-1]
+	reversal.ReversalOf = &transactionID
+	reversal.Reason = reason
+	reversal.Actor = actor
+	m.transactions[transactionID-1].ReversedBy = &reversalID
+	return *reversal
+}
+
+// LogTransaction keeps the audit log that MongoDB holds in production
+func (m *Memory) LogTransaction(accountID int, amount models.Money, txType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.auditLog = append(m.auditLog, models.Transaction{AccountID: accountID, Amount: amount, Type: txType, CreatedAt: time.Now()})
+}
+
+func (m *Memory) GetOperation(id int) (*models.Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.operations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *op
+	return &copied, nil
+}
+
+func (m *Memory) GetOperationByIdempotencyKey(key string) (*models.Operation, error) {
+	m.mu.Lock()
+	id, ok := m.operationKeys[key]
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return m.GetOperation(id)
+}
+
+func (m *Memory) MarkOperationProcessing(id int) error {
+	return m.setOperationStatus(id, models.OperationProcessing, "", 0)
+}
+
+func (m *Memory) MarkOperationSucceeded(id int, accountID int) error {
+	return m.setOperationStatus(id, models.OperationSucceeded, "", accountID)
+}
+
+func (m *Memory) MarkOperationFailed(id int, reason string) error {
+	return m.setOperationStatus(id, models.OperationFailed, reason, 0)
+}
+
+func (m *Memory) setOperationStatus(id int, status string, reason string, accountID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.operations[id]
+	if !ok {
+		return nil
+	}
+	op.Status = status
+	op.Reason = reason
+	if accountID != 0 {
+		op.AccountID = &accountID
+	}
+	op.UpdatedAt = time.Now()
+	return nil
+}
+
+// Publish records a queued operation and hands env to the worker, adding
+// the operation's ID and the idempotency key to it
+func (m *Memory) Publish(opType string, idempotencyKey string, env *messages.Envelope) (*models.Operation, bool, error) {
+	m.mu.Lock()
+	if id, ok := m.operationKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		op := *m.operations[id]
+		m.mu.Unlock()
+		return &op, false, nil
+	}
+
+	m.nextOperationID++
+	now := time.Now()
+	op := &models.Operation{ID: m.nextOperationID, Type: opType, Status: models.OperationQueued, CreatedAt: now, UpdatedAt: now}
+	env.OperationID = op.ID
+	env.IdempotencyKey = idempotencyKey
+	body, err := env.Encode()
+	if err != nil {
+		m.mu.Unlock()
+		return nil, false, err
+	}
+
+	m.operations[op.ID] = op
+	if idempotencyKey != "" {
+		m.operationKeys[idempotencyKey] = op.ID
+	}
+	created := *op
+	m.mu.Unlock()
+
+	// Publish outside the lock, the worker reads the store while consuming
+	if err := m.publish(body); err != nil {
+		log.Println("Failed to publish message:", err)
+		m.mu.Lock()
+		delete(m.operations, op.ID)
+		delete(m.operationKeys, idempotencyKey)
+		m.mu.Unlock()
+		return nil, false, err
+	}
+	return &created, true, nil
+}
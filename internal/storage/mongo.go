@@ -1,12 +1,14 @@
 package storage
 
 import (
+	"banking-ledger-service/internal/models"
 	"context"
 	"log"
 	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -54,16 +56,23 @@ func InitMongoDB() {
 }
 
 // LogTransactionToMongo stores transaction logs in MongoDB
-func LogTransactionToMongo(accountID int, amount float64, txType string) {
+func LogTransactionToMongo(accountID int, amount models.Money, txType string) {
+	// Store the amount as an exact decimal rather than a binary float
+	decimalAmount, err := primitive.ParseDecimal128(amount.String())
+	if err != nil {
+		log.Println("Failed to convert amount for MongoDB:", err)
+		return
+	}
+
 	// Create a new document
 	doc := bson.M{
 		"account_id": accountID,
-		"amount":     amount,
+		"amount":     decimalAmount,
 		"type":       txType,
 		"timestamp":  time.Now(),
 	}
 	// Insert the document into the collection
-	_, err := transactionCollection.InsertOne(context.Background(), doc)
+	_, err = transactionCollection.InsertOne(context.Background(), doc)
 	if err != nil {
 		log.Println("Failed to insert transaction log into MongoDB:", err)
 	} else {
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnqueueOperation records a new queued operation together with the message
+// that asks the worker to carry it out. Both rows are written in one
+// transaction, so the outbox relay publishes the message if and only if the
+// operation exists. build receives the new operation ID and returns the
+// message payload. When idempotencyKey is non-empty and already belongs to an
+// operation, that operation is returned instead, nothing is written and
+// created is false.
+func EnqueueOperation(opType string, idempotencyKey string, build func(opID int) (string, error)) (op *models.Operation, created bool, err error) {
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(context.Background())
+
+	var id int
+	err = tx.QueryRow(context.Background(),
+		"INSERT INTO operations (type, status, idempotency_key) VALUES ($1, $2, NULLIF($3, '')) ON CONFLICT (idempotency_key) DO NOTHING RETURNING id",
+		opType, models.OperationQueued, idempotencyKey).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		op, err = GetOperationByIdempotencyKey(idempotencyKey)
+		return op, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	payload, err := build(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.Exec(context.Background(), "INSERT INTO outbox (payload) VALUES ($1)", payload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, false, err
+	}
+	return &models.Operation{ID: id, Type: opType, Status: models.OperationQueued}, true, nil
+}
+
+// GetOperation fetches an operation by ID
+func GetOperation(id int) (*models.Operation, error) {
+	return scanOperation(DB.QueryRow(context.Background(), operationSelect+" WHERE id = $1", id))
+}
+
+// GetOperationByIdempotencyKey fetches the operation created with the given key
+func GetOperationByIdempotencyKey(key string) (*models.Operation, error) {
+	return scanOperation(DB.QueryRow(context.Background(), operationSelect+" WHERE idempotency_key = $1", key))
+}
+
+const operationSelect = "SELECT id, type, status, reason, account_id, created_at, updated_at FROM operations"
+
+func scanOperation(row pgx.Row) (*models.Operation, error) {
+	var op models.Operation
+	var reason *string
+	err := row.Scan(&op.ID, &op.Type, &op.Status, &reason, &op.AccountID, &op.CreatedAt, &op.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if reason != nil {
+		op.Reason = *reason
+	}
+	return &op, nil
+}
+
+// MarkOperationProcessing flags an operation as picked up by the worker
+func MarkOperationProcessing(id int) error {
+	return setOperationStatus(id, models.OperationProcessing, nil, nil)
+}
+
+// MarkOperationSucceeded completes an operation against the given account
+func MarkOperationSucceeded(id int, accountID int) error {
+	return setOperationStatus(id, models.OperationSucceeded, nil, &accountID)
+}
+
+// MarkOperationFailed completes an operation with a failure reason
+func MarkOperationFailed(id int, reason string) error {
+	return setOperationStatus(id, models.OperationFailed, &reason, nil)
+}
+
+func setOperationStatus(id int, status string, reason *string, accountID *int) error {
+	_, err := DB.Exec(context.Background(),
+		"UPDATE operations SET status = $1, reason = $2, account_id = COALESCE($3, account_id), updated_at = CURRENT_TIMESTAMP WHERE id = $4",
+		status, reason, accountID, id)
+	return err
+}
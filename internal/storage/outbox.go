@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// RelayOutbox publishes up to limit unsent outbox messages in the order they
+// were written and marks each one sent once publish returns successfully. The
+// batch is first claimed by hiding it from other relays for claimFor, so no
+// transaction or row lock is held while the broker is slow to confirm; a
+// relay that stops mid-batch leaves its messages to be sent again once the
+// claim runs out. Relaying stops at the first failed publish, which is
+// recorded against the message and retried on the next call, and the rest of
+// the batch is released. It returns the number of messages sent.
+func RelayOutbox(limit int, claimFor time.Duration, publish func(payload string) error) (int, error) {
+	ctx := context.Background()
+	rows, err := DB.Query(ctx, `
+		UPDATE outbox SET locked_until = CURRENT_TIMESTAMP + $1 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND (locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, payload`,
+		claimFor.Milliseconds(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	type message struct {
+		id      int64
+		payload string
+	}
+	var batch []message
+	for rows.Next() {
+		var m message
+		if err := rows.Scan(&m.id, &m.payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// UPDATE ... RETURNING does not preserve the subquery's order
+	sort.Slice(batch, func(i, j int) bool { return batch[i].id < batch[j].id })
+
+	sent := 0
+	for i, m := range batch {
+		if err := publish(m.payload); err != nil {
+			log.Println("Failed to relay outbox message:", err)
+			_, err = DB.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $1, locked_until = NULL WHERE id = $2", err.Error(), m.id)
+			if err != nil {
+				return sent, err
+			}
+
+			// Release the rest so the next call retries in order
+			var rest []int64
+			for _, m := range batch[i+1:] {
+				rest = append(rest, m.id)
+			}
+			_, err = DB.Exec(ctx, "UPDATE outbox SET locked_until = NULL WHERE id = ANY($1)", rest)
+			return sent, err
+		}
+
+		_, err = DB.Exec(ctx, "UPDATE outbox SET sent_at = CURRENT_TIMESTAMP, attempts = attempts + 1, locked_until = NULL WHERE id = $1", m.id)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"time"
+)
+
+// OverdraftAccount is an account the interest engine accrues or charges
+// overdraft interest for. RateBps is zero when the rate was removed with
+// accruals still uncharged.
+type OverdraftAccount struct {
+	AccountID      int
+	RateBps        int
+	Since          time.Time  // Day overdraft interest started accruing
+	AccruedThrough *time.Time // Last day accrued, if any
+}
+
+// SetOverdraft sets how far below zero debits may take an account's balance
+// and the annual rate charged on a negative balance. A nil rateBps keeps the
+// current rate. Overdraft interest accrues from since once a rate is set;
+// lowering the limit never changes the balance.
+func SetOverdraft(accountID int, limit models.Money, rateBps *int, since time.Time) error {
+	tag, err := DB.Exec(context.Background(),
+		`UPDATE accounts SET overdraft_limit = $1,
+			overdraft_rate_bps = COALESCE($2, overdraft_rate_bps),
+			overdraft_since = CASE WHEN COALESCE($2, overdraft_rate_bps) = 0 THEN NULL ELSE COALESCE(overdraft_since, $3::date) END
+		WHERE id = $4 AND NOT is_system`,
+		limit, rateBps, since.UTC(), accountID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// OverdraftAccounts returns the accounts with an overdraft rate or with
+// accruals that were never charged
+func OverdraftAccounts() ([]OverdraftAccount, error) {
+	rows, err := DB.Query(context.Background(), `
+		SELECT id, overdraft_rate_bps, COALESCE(overdraft_since, CURRENT_DATE),
+			(SELECT MAX(accrual_date) FROM overdraft_accruals WHERE account_id = accounts.id)
+		FROM accounts
+		WHERE overdraft_rate_bps > 0
+			OR EXISTS (SELECT 1 FROM overdraft_accruals WHERE account_id = accounts.id AND transaction_id IS NULL)
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []OverdraftAccount
+	for rows.Next() {
+		var a OverdraftAccount
+		if err := rows.Scan(&a.AccountID, &a.RateBps, &a.Since, &a.AccruedThrough); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// AddOverdraftAccrual records one day's overdraft interest, ignoring a day
+// already accrued
+func AddOverdraftAccrual(a models.OverdraftAccrual) error {
+	_, err := DB.Exec(context.Background(),
+		"INSERT INTO overdraft_accruals (account_id, accrual_date, balance, amount) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		a.AccountID, a.Date, a.Balance, a.Amount)
+	return err
+}
+
+// ListOverdraftAccruals returns an account's overdraft accruals, newest first
+func ListOverdraftAccruals(accountID int) ([]models.OverdraftAccrual, error) {
+	rows, err := DB.Query(context.Background(),
+		"SELECT account_id, accrual_date, balance, amount, transaction_id FROM overdraft_accruals WHERE account_id = $1 ORDER BY accrual_date DESC",
+		accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accruals := []models.OverdraftAccrual{}
+	for rows.Next() {
+		var a models.OverdraftAccrual
+		if err := rows.Scan(&a.AccountID, &a.Date, &a.Balance, &a.Amount, &a.TransactionID); err != nil {
+			return nil, err
+		}
+		accruals = append(accruals, a)
+	}
+	return accruals, rows.Err()
+}
+
+// ChargeOverdraftInterest debits an account the uncharged overdraft interest
+// it accrued up to and including through, rounded to the nearest cent, pays
+// it to the interest system account and records an overdraft_interest
+// transaction. It is charged even past the overdraft limit. Like
+// PostInterest it returns nil when less than half a cent is due.
+func ChargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	return postAccruedNow(accountID, through, overdraftAccruals)
+}
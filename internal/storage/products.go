@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrProductRule is returned when a transaction breaks a rule of its
+// account's product, such as its minimum balance or withdrawal limits
+var ErrProductRule = errors.New("account product does not allow this transaction")
+
+// withdrawalTypes lists the transaction types counted against a product's
+// monthly withdrawal limit
+const withdrawalTypes = "('withdraw', 'transfer_out')"
+
+// checkProduct fails with ErrProductRule unless a locked account's product
+// allows a transaction of txType and amount, where available is the most a
+// debit may take from the account. The overdraft limit included in available
+// does not count toward the product's minimum balance.
+func checkProduct(ctx context.Context, tx pgx.Tx, accountID int, txType string, amount, available models.Money) error {
+	var code string
+	var limit models.Money
+	var withdrawals int
+	err := tx.QueryRow(ctx,
+		`SELECT product_code, overdraft_limit, (SELECT COUNT(*) FROM transactions
+			WHERE account_id = accounts.id AND type IN `+withdrawalTypes+` AND created_at >= date_trunc('month', CURRENT_TIMESTAMP))
+		FROM accounts WHERE id = $1`,
+		accountID).Scan(&code, &limit, &withdrawals)
+	if err != nil {
+		return fmt.Errorf("account %d: %w", accountID, notFound(err))
+	}
+	return productRule(code, txType, amount, available-limit, withdrawals)
+}
+
+// productRule applies products.Check for the product with code
+func productRule(code, txType string, amount, available models.Money, withdrawals int) error {
+	product, ok := products.Get(code)
+	if !ok {
+		return fmt.Errorf("%w: unknown product %q", ErrProductRule, code)
+	}
+	if err := products.Check(product, txType, amount, available, withdrawals); err != nil {
+		return fmt.Errorf("%w: %v", ErrProductRule, err)
+	}
+	return nil
+}
+
+// openingProduct returns the product an account opens on, the default when
+// code is empty, and fails with ErrProductRule when balance is too low for it
+func openingProduct(code string, balance models.Money) (models.Product, error) {
+	if code == "" {
+		code = products.Default
+	}
+	product, ok := products.Get(code)
+	if !ok {
+		return product, fmt.Errorf("%w: unknown product %q", ErrProductRule, code)
+	}
+	if err := products.CheckOpening(product, balance); err != nil {
+		return product, fmt.Errorf("%w: %v", ErrProductRule, err)
+	}
+	return product, nil
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned when a requested account or operation does not exist
+var ErrNotFound = errors.New("not found")
+
+// AccountRepository reads accounts and applies balance changes to them
+type AccountRepository interface {
+	CreateAccount(customerID *int, name, productCode, currency string, balance models.Money, idempotencyKey string) (int, error)
+	GetAccount(id int) (*models.Account, error)
+	UpdateBalance(accountID int, amount models.Money, operation string, idempotencyKey string) error
+	Transfer(fromID, toID int, amount models.Money, idempotencyKey string) error
+	SetAccountType(id int, accountType string) error
+	SetOverdraft(id int, limit models.Money, rateBps *int, since time.Time) error
+	ChangeAccountStatus(id int, action, reason, actor string) error
+	CloseAccount(id int, reason, actor string, payout bool) error
+	ListAccountEvents(id int) ([]models.AccountEvent, error)
+	MarkDormant(inactiveSince time.Time) (int, error)
+}
+
+// CustomerRepository manages the customers who hold accounts
+type CustomerRepository interface {
+	CreateCustomer(c models.Customer) (*models.Customer, error)
+	GetCustomer(id int) (*models.Customer, error)
+	ListCustomers() ([]models.Customer, error)
+	UpdateCustomer(c models.Customer) (*models.Customer, error)
+	DeleteCustomer(id int) error
+	ListCustomerAccounts(customerID int) ([]models.Account, error)
+}
+
+// TransactionRepository reads transaction history, reverses posted
+// transactions and writes the audit log
+type TransactionRepository interface {
+	ListTransactions(q TransactionQuery) ([]models.Transaction, error)
+	GetTransaction(id int) (*models.Transaction, error)
+	ReverseTransaction(transactionID int, reason, actor, idempotencyKey string) (*models.Transaction, error)
+	LogTransaction(accountID int, amount models.Money, txType string)
+}
+
+// OperationRepository tracks the lifecycle of queued operations
+type OperationRepository interface {
+	GetOperation(id int) (*models.Operation, error)
+	GetOperationByIdempotencyKey(key string) (*models.Operation, error)
+	MarkOperationProcessing(id int) error
+	MarkOperationSucceeded(id int, accountID int) error
+	MarkOperationFailed(id int, reason string) error
+}
+
+// HoldRepository places and settles holds on account funds
+type HoldRepository interface {
+	PlaceHold(accountID int, amount models.Money, reference string, expiresAt time.Time) (*models.Hold, error)
+	GetHold(id int) (*models.Hold, error)
+	CaptureHold(id int, amount models.Money) (*models.Hold, error)
+	ReleaseHold(id int) (*models.Hold, error)
+}
+
+// ScheduleRepository stores standing orders and tracks their next occurrence
+type ScheduleRepository interface {
+	CreateSchedule(s models.Schedule) (*models.Schedule, error)
+	GetSchedule(id int) (*models.Schedule, error)
+	ListSchedules(accountID int) ([]models.Schedule, error)
+	SetScheduleStatus(id int, status string, nextRunAt *time.Time) (*models.Schedule, error)
+	DueSchedules(now time.Time, limit int) ([]models.Schedule, error)
+	AdvanceSchedule(id int, from time.Time, next *time.Time) (bool, error)
+}
+
+// InterestRepository stores interest products and the interest accounts
+// accrue on them or are charged on their overdrafts
+type InterestRepository interface {
+	CreateInterestProduct(p models.InterestProduct) (*models.InterestProduct, error)
+	GetInterestProduct(id int) (*models.InterestProduct, error)
+	ListInterestProducts() ([]models.InterestProduct, error)
+	SetInterestProduct(accountID int, productID *int, since time.Time) error
+	InterestAccounts() ([]InterestAccount, error)
+	EndOfDayBalance(accountID int, day time.Time) (models.Money, error)
+	AddAccrual(a models.InterestAccrual) error
+	ListAccruals(accountID int) ([]models.InterestAccrual, error)
+	PostInterest(accountID int, through time.Time) (*models.Transaction, error)
+	OverdraftAccounts() ([]OverdraftAccount, error)
+	AddOverdraftAccrual(a models.OverdraftAccrual) error
+	ListOverdraftAccruals(accountID int) ([]models.OverdraftAccrual, error)
+	ChargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error)
+}
+
+// FeeRepository manages fee rules and previews the fees they charge
+type FeeRepository interface {
+	CreateFeeRule(r models.FeeRule) (*models.FeeRule, error)
+	ListFeeRules() ([]models.FeeRule, error)
+	DeactivateFeeRule(id int) (*models.FeeRule, error)
+	PreviewFees(accountID int, txType string, amount models.Money) ([]models.FeeCharge, error)
+}
+
+// Postgres implements the repositories on top of the package-level
+// PostgreSQL pool and MongoDB transaction log
+type Postgres struct{}
+
+func (Postgres) CreateAccount(customerID *int, name, productCode, currency string, balance models.Money, idempotencyKey string) (int, error) {
+	return CreateAccount(customerID, name, productCode, currency, balance, idempotencyKey)
+}
+
+func (Postgres) GetAccount(id int) (*models.Account, error) {
+	return GetAccount(id)
+}
+
+func (Postgres) UpdateBalance(accountID int, amount models.Money, operation string, idempotencyKey string) error {
+	return UpdateBalance(accountID, amount, operation, idempotencyKey)
+}
+
+func (Postgres) Transfer(fromID, toID int, amount models.Money, idempotencyKey string) error {
+	return Transfer(fromID, toID, amount, idempotencyKey)
+}
+
+func (Postgres) SetAccountType(id int, accountType string) error {
+	return SetAccountType(id, accountType)
+}
+
+func (Postgres) SetOverdraft(id int, limit models.Money, rateBps *int, since time.Time) error {
+	return SetOverdraft(id, limit, rateBps, since)
+}
+
+func (Postgres) ChangeAccountStatus(id int, action, reason, actor string) error {
+	return ChangeAccountStatus(id, action, reason, actor)
+}
+
+func (Postgres) CloseAccount(id int, reason, actor string, payout bool) error {
+	return CloseAccount(id, reason, actor, payout)
+}
+
+func (Postgres) ListAccountEvents(id int) ([]models.AccountEvent, error) {
+	return ListAccountEvents(id)
+}
+
+func (Postgres) MarkDormant(inactiveSince time.Time) (int, error) {
+	return MarkDormant(inactiveSince)
+}
+
+func (Postgres) CreateCustomer(c models.Customer) (*models.Customer, error) {
+	return CreateCustomer(c)
+}
+
+func (Postgres) GetCustomer(id int) (*models.Customer, error) {
+	return GetCustomer(id)
+}
+
+func (Postgres) ListCustomers() ([]models.Customer, error) {
+	return ListCustomers()
+}
+
+func (Postgres) UpdateCustomer(c models.Customer) (*models.Customer, error) {
+	return UpdateCustomer(c)
+}
+
+func (Postgres) DeleteCustomer(id int) error {
+	return DeleteCustomer(id)
+}
+
+func (Postgres) ListCustomerAccounts(customerID int) ([]models.Account, error) {
+	return ListCustomerAccounts(customerID)
+}
+
+func (Postgres) ListTransactions(q TransactionQuery) ([]models.Transaction, error) {
+	return ListTransactions(q)
+}
+
+func (Postgres) GetTransaction(id int) (*models.Transaction, error) {
+	return GetTransaction(id)
+}
+
+func (Postgres) ReverseTransaction(transactionID int, reason, actor, idempotencyKey string) (*models.Transaction, error) {
+	return ReverseTransaction(transactionID, reason, actor, idempotencyKey)
+}
+
+func (Postgres) LogTransaction(accountID int, amount models.Money, txType string) {
+	LogTransactionToMongo(accountID, amount, txType)
+}
+
+func (Postgres) GetOperation(id int) (*models.Operation, error) {
+	return GetOperation(id)
+}
+
+func (Postgres) GetOperationByIdempotencyKey(key string) (*models.Operation, error) {
+	return GetOperationByIdempotencyKey(key)
+}
+
+func (Postgres) MarkOperationProcessing(id int) error {
+	return MarkOperationProcessing(id)
+}
+
+func (Postgres) MarkOperationSucceeded(id int, accountID int) error {
+	return MarkOperationSucceeded(id, accountID)
+}
+
+func (Postgres) MarkOperationFailed(id int, reason string) error {
+	return MarkOperationFailed(id, reason)
+}
+
+func (Postgres) PlaceHold(accountID int, amount models.Money, reference string, expiresAt time.Time) (*models.Hold, error) {
+	return PlaceHold(accountID, amount, reference, expiresAt)
+}
+
+func (Postgres) GetHold(id int) (*models.Hold, error) {
+	return GetHold(id)
+}
+
+func (Postgres) CaptureHold(id int, amount models.Money) (*models.Hold, error) {
+	return CaptureHold(id, amount)
+}
+
+func (Postgres) ReleaseHold(id int) (*models.Hold, error) {
+	return ReleaseHold(id)
+}
+
+func (Postgres) CreateSchedule(s models.Schedule) (*models.Schedule, error) {
+	return CreateSchedule(s)
+}
+
+func (Postgres) GetSchedule(id int) (*models.Schedule, error) {
+	return GetSchedule(id)
+}
+
+func (Postgres) ListSchedules(accountID int) ([]models.Schedule, error) {
+	return ListSchedules(accountID)
+}
+
+func (Postgres) SetScheduleStatus(id int, status string, nextRunAt *time.Time) (*models.Schedule, error) {
+	return SetScheduleStatus(id, status, nextRunAt)
+}
+
+func (Postgres) DueSchedules(now time.Time, limit int) ([]models.Schedule, error) {
+	return DueSchedules(now, limit)
+}
+
+func (Postgres) AdvanceSchedule(id int, from time.Time, next *time.Time) (bool, error) {
+	return AdvanceSchedule(id, from, next)
+}
+
+func (Postgres) CreateInterestProduct(p models.InterestProduct) (*models.InterestProduct, error) {
+	return CreateInterestProduct(p)
+}
+
+func (Postgres) GetInterestProduct(id int) (*models.InterestProduct, error) {
+	return GetInterestProduct(id)
+}
+
+func (Postgres) ListInterestProducts() ([]models.InterestProduct, error) {
+	return ListInterestProducts()
+}
+
+func (Postgres) SetInterestProduct(accountID int, productID *int, since time.Time) error {
+	return SetInterestProduct(accountID, productID, since)
+}
+
+func (Postgres) InterestAccounts() ([]InterestAccount, error) {
+	return InterestAccounts()
+}
+
+func (Postgres) EndOfDayBalance(accountID int, day time.Time) (models.Money, error) {
+	return EndOfDayBalance(accountID, day)
+}
+
+func (Postgres) AddAccrual(a models.InterestAccrual) error {
+	return AddAccrual(a)
+}
+
+func (Postgres) ListAccruals(accountID int) ([]models.InterestAccrual, error) {
+	return ListAccruals(accountID)
+}
+
+func (Postgres) PostInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	return PostInterest(accountID, through)
+}
+
+func (Postgres) OverdraftAccounts() ([]OverdraftAccount, error) {
+	return OverdraftAccounts()
+}
+
+func (Postgres) AddOverdraftAccrual(a models.OverdraftAccrual) error {
+	return AddOverdraftAccrual(a)
+}
+
+func (Postgres) ListOverdraftAccruals(accountID int) ([]models.OverdraftAccrual, error) {
+	return ListOverdraftAccruals(accountID)
+}
+
+func (Postgres) ChargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	return ChargeOverdraftInterest(accountID, through)
+}
+
+func (Postgres) CreateFeeRule(r models.FeeRule) (*models.FeeRule, error) {
+	return CreateFeeRule(r)
+}
+
+func (Postgres) ListFeeRules() ([]models.FeeRule, error) {
+	return ListFeeRules()
+}
+
+func (Postgres) DeactivateFeeRule(id int) (*models.FeeRule, error) {
+	return DeactivateFeeRule(id)
+}
+
+func (Postgres) PreviewFees(accountID int, txType string, amount models.Money) ([]models.FeeCharge, error) {
+	return PreviewFees(accountID, txType, amount)
+}
+
+// Publish queues env for the worker through the outbox, adding the new
+// operation's ID and the idempotency key to it
+func (Postgres) Publish(opType string, idempotencyKey string, env *messages.Envelope) (*models.Operation, bool, error) {
+	return EnqueueOperation(opType, idempotencyKey, func(opID int) (string, error) {
+		env.OperationID = opID
+		env.IdempotencyKey = idempotencyKey
+		return env.Encode()
+	})
+}
+
+// notFound translates a missing row into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotReversible is returned when reversing a transaction that is neither
+// a deposit nor a withdrawal
+var ErrNotReversible = errors.New("only deposits and withdrawals can be reversed")
+
+// ErrAlreadyReversed is returned when a transaction has already been reversed
+var ErrAlreadyReversed = errors.New("transaction already reversed")
+
+// ReverseTransaction undoes a posted deposit or withdrawal with a
+// compensating journal entry and records a reversal transaction linked to
+// the original, with the reason and the actor who requested it. Fees the
+// original was charged are refunded the same way, each with a reversal of
+// its own. The original row is locked so a transaction can only be reversed
+// once; reversing a deposit whose funds have since been spent returns
+// ErrInsufficientFunds. A repeated idempotency key returns
+// ErrDuplicateRequest.
+func ReverseTransaction(transactionID int, reason, actor, idempotencyKey string) (*models.Transaction, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback transaction if any error occurs
+	defer tx.Rollback(ctx)
+
+	if _, err := claimIdempotencyKey(ctx, tx, idempotencyKey, 0); err != nil {
+		return nil, err
+	}
+
+	original, err := scanTransaction(tx.QueryRow(ctx, "SELECT "+transactionColumns+" FROM transactions t WHERE id = $1 FOR UPDATE", transactionID))
+	if err != nil {
+		return nil, fmt.Errorf("lock transaction %d: %w", transactionID, notFound(err))
+	}
+	if original.ReversedBy != nil {
+		return nil, ErrAlreadyReversed
+	}
+
+	available, err := lockAvailable(ctx, tx, original.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reversals correct mistakes, so they are allowed on frozen accounts
+	if err := requireStatus(ctx, tx, original.AccountID, false); err != nil {
+		return nil, err
+	}
+
+	charged, err := lockFees(ctx, tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	var refund models.Money
+	for _, fee := range charged {
+		refund += fee.Amount
+	}
+
+	// Post the original entry's legs in the opposite direction
+	var postings []posting
+	switch original.Type {
+	case "deposit":
+		if available+refund < original.Amount {
+			return nil, ErrInsufficientFunds
+		}
+		cashIn, err := systemAccountID(ctx, tx, CashInAccount, original.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		postings = []posting{{accountID: original.AccountID, amount: -original.Amount}, {accountID: cashIn, amount: original.Amount}}
+	case "withdraw":
+		cashOut, err := systemAccountID(ctx, tx, CashOutAccount, original.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		postings = []posting{{accountID: cashOut, amount: -original.Amount}, {accountID: original.AccountID, amount: original.Amount}}
+	default:
+		return nil, ErrNotReversible
+	}
+
+	// Refund the fees first, so a reversed deposit never overdraws
+	if len(charged) > 0 {
+		feesID, err := systemAccountID(ctx, tx, FeesAccount, original.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, fee := range charged {
+			_, err := addReversal(ctx, tx, fee, reason, actor,
+				posting{accountID: feesID, amount: -fee.Amount},
+				posting{accountID: fee.AccountID, amount: fee.Amount})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	reversal, err := addReversal(ctx, tx, original, reason, actor, postings...)
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, tx.Commit(ctx)
+}
+
+// lockFees locks and returns the fee transactions charged with a transaction
+// that have not been reversed
+func lockFees(ctx context.Context, tx pgx.Tx, transactionID int) ([]*models.Transaction, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT "+transactionColumns+" FROM transactions t WHERE fee_for = $1 AND NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id) ORDER BY id FOR UPDATE",
+		transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var charged []*models.Transaction
+	for rows.Next() {
+		fee, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		charged = append(charged, fee)
+	}
+	return charged, rows.Err()
+}
+
+// addReversal posts a compensating entry for original and records the
+// reversal transaction linked to it
+func addReversal(ctx context.Context, tx pgx.Tx, original *models.Transaction, reason, actor string, postings ...posting) (*models.Transaction, error) {
+	entryID, balances, err := postEntry(ctx, tx, fmt.Sprintf("reversal of transaction %d", original.ID), postings...)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal := models.Transaction{
+		AccountID:  original.AccountID,
+		Amount:     original.Amount,
+		Type:       "reversal",
+		ReversalOf: &original.ID,
+		Reason:     reason,
+		Actor:      actor,
+	}
+	balanceAfter := balances[original.AccountID]
+	reversal.BalanceAfter = &balanceAfter
+	err = tx.QueryRow(ctx,
+		"INSERT INTO transactions (account_id, currency, amount, type, entry_id, balance_after, reversal_of, reason, actor) VALUES ($1, (SELECT currency FROM accounts WHERE id = $1), $2, $3, $4, $5, $6, $7, $8) RETURNING id, currency, created_at",
+		reversal.AccountID, reversal.Amount, reversal.Type, entryID, balanceAfter, original.ID, reason, actor).
+		Scan(&reversal.ID, &reversal.Currency, &reversal.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reversal, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrScheduleClosed is returned when changing a schedule that was cancelled
+// or has completed
+var ErrScheduleClosed = errors.New("schedule is cancelled or completed")
+
+// CreateSchedule stores a new schedule with its first occurrence already set
+// in NextRunAt
+func CreateSchedule(s models.Schedule) (*models.Schedule, error) {
+	var cron *string
+	if s.Cron != "" {
+		cron = &s.Cron
+	}
+	var interval *int64
+	if s.IntervalSeconds != 0 {
+		interval = &s.IntervalSeconds
+	}
+
+	return scanSchedule(DB.QueryRow(context.Background(),
+		`INSERT INTO schedules (type, account_id, to_account_id, amount, cron, interval_seconds, start_at, end_at, next_run_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING `+scheduleColumns,
+		s.Type, s.AccountID, s.ToAccountID, s.Amount, cron, interval,
+		s.StartAt.UTC(), utc(s.EndAt), utc(s.NextRunAt), s.Status))
+}
+
+// GetSchedule fetches a schedule by ID
+func GetSchedule(id int) (*models.Schedule, error) {
+	return scanSchedule(DB.QueryRow(context.Background(), "SELECT "+scheduleColumns+" FROM schedules WHERE id = $1", id))
+}
+
+// ListSchedules returns the schedules debiting or crediting an account
+func ListSchedules(accountID int) ([]models.Schedule, error) {
+	return querySchedules("SELECT "+scheduleColumns+" FROM schedules WHERE account_id = $1 OR to_account_id = $1 ORDER BY id", accountID)
+}
+
+// SetScheduleStatus pauses, resumes, cancels or completes a schedule that is
+// still active or paused. A nil nextRunAt keeps the current next occurrence.
+func SetScheduleStatus(id int, status string, nextRunAt *time.Time) (*models.Schedule, error) {
+	ctx := context.Background()
+	s, err := scanSchedule(DB.QueryRow(ctx,
+		`UPDATE schedules SET status = $1, next_run_at = COALESCE($2, next_run_at), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status IN ('active', 'paused') RETURNING `+scheduleColumns,
+		status, utc(nextRunAt), id))
+	if !errors.Is(err, ErrNotFound) {
+		return s, err
+	}
+
+	// Tell a missing schedule from a closed one
+	if _, err := GetSchedule(id); err != nil {
+		return nil, err
+	}
+	return nil, ErrScheduleClosed
+}
+
+// DueSchedules returns up to limit active schedules whose next occurrence is
+// at or before now, earliest first
+func DueSchedules(now time.Time, limit int) ([]models.Schedule, error) {
+	return querySchedules("SELECT "+scheduleColumns+" FROM schedules WHERE status = 'active' AND next_run_at <= $1 ORDER BY next_run_at LIMIT $2", now.UTC(), limit)
+}
+
+// AdvanceSchedule moves an active schedule's next occurrence from from to
+// next after the occurrences in between were queued, and completes it when
+// next is nil. It reports false when the schedule was paused, cancelled or
+// advanced by someone else in the meantime.
+func AdvanceSchedule(id int, from time.Time, next *time.Time) (bool, error) {
+	tag, err := DB.Exec(context.Background(),
+		`UPDATE schedules SET next_run_at = $1, last_run_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN $1::timestamp IS NULL THEN 'completed' ELSE status END
+		WHERE id = $2 AND status = 'active' AND next_run_at = $3`,
+		utc(next), id, from.UTC())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+const scheduleColumns = "id, type, account_id, to_account_id, amount, COALESCE(cron, ''), COALESCE(interval_seconds, 0), start_at, end_at, next_run_at, last_run_at, status, created_at, updated_at"
+
+func scanSchedule(row pgx.Row) (*models.Schedule, error) {
+	var s models.Schedule
+	err := row.Scan(&s.ID, &s.Type, &s.AccountID, &s.ToAccountID, &s.Amount, &s.Cron, &s.IntervalSeconds,
+		&s.StartAt, &s.EndAt, &s.NextRunAt, &s.LastRunAt, &s.Status, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func querySchedules(sql string, args ...any) ([]models.Schedule, error) {
+	rows, err := DB.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
+
+// utc converts an optional time to UTC for the timestamp columns
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"banking-ledger-service/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TransactionQuery selects a page of an account's transaction history.
+// Zero-valued filters are ignored.
+type TransactionQuery struct {
+	AccountID  int
+	Type       string
+	MinAmount  *models.Money
+	MaxAmount  *models.Money
+	From       *time.Time // Inclusive lower bound on created_at
+	To         *time.Time // Exclusive upper bound on created_at
+	Descending bool
+	AfterID    int // Keyset cursor: the ID of the last row of the previous page
+	Limit      int
+}
+
+// ListTransactions returns the transactions matching q ordered by ID
+func ListTransactions(q TransactionQuery) ([]models.Transaction, error) {
+	conditions := []string{"account_id = $1"}
+	args := []any{q.AccountID}
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.Type != "" {
+		add("type = $%d", q.Type)
+	}
+	if q.MinAmount != nil {
+		add("amount >= $%d", *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		add("amount <= $%d", *q.MaxAmount)
+	}
+	if q.From != nil {
+		add("created_at >= $%d", *q.From)
+	}
+	if q.To != nil {
+		add("created_at < $%d", *q.To)
+	}
+
+	order := "ASC"
+	if q.Descending {
+		order = "DESC"
+		if q.AfterID != 0 {
+			add("id < $%d", q.AfterID)
+		}
+	} else if q.AfterID != 0 {
+		add("id > $%d", q.AfterID)
+	}
+
+	args = append(args, q.Limit)
+	query := fmt.Sprintf("SELECT "+transactionColumns+" FROM transactions t WHERE %s ORDER BY id %s LIMIT $%d",
+		strings.Join(conditions, " AND "), order, len(args))
+
+	rows, err := DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *t)
+	}
+	return transactions, rows.Err()
+}
+
+// GetTransaction fetches a transaction by ID
+func GetTransaction(id int) (*models.Transaction, error) {
+	t, err := scanTransaction(DB.QueryRow(context.Background(), "SELECT "+transactionColumns+" FROM transactions t WHERE id = $1", id))
+	return t, notFound(err)
+}
+
+const transactionColumns = `id, account_id, amount, currency, type, balance_after, reversal_of,
+	(SELECT r.id FROM transactions r WHERE r.reversal_of = t.id), fee_for, COALESCE(reason, ''), COALESCE(actor, ''), created_at`
+
+func scanTransaction(row pgx.Row) (*models.Transaction, error) {
+	var t models.Transaction
+	err := row.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Currency, &t.Type, &t.BalanceAfter, &t.ReversalOf, &t.ReversedBy, &t.FeeFor, &t.Reason, &t.Actor, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/queue"
+	"hash/fnv"
+	"sync"
+)
+
+// Run processes deliveries until the channel closes. Each account's messages
+// are processed in order while different accounts proceed in parallel across
+// concurrency lanes. Each lane buffers up to prefetch deliveries, the most
+// the consumer hands out unacknowledged, so a busy lane never holds up the
+// others.
+func (p *Processor) Run(deliveries <-chan queue.Delivery, concurrency, prefetch int) {
+	lanes := newLaneDispatcher(concurrency, prefetch, p.ProcessTransaction)
+	for msg := range deliveries {
+		lanes.dispatch(msg)
+	}
+	lanes.close()
+}
+
+// laneDispatcher fans deliveries out to a fixed pool of serial lanes keyed
+// by account. Messages for one account always land on the same lane and are
+// processed in the order they were received, while different accounts are
+// processed in parallel across lanes. A message for accounts on several
+// lanes, such as a transfer, is queued on each of them and processed once
+// all of them reach it, so it stays in order with both accounts.
+type laneDispatcher struct {
+	lanes []chan laneItem
+	wg    sync.WaitGroup
+}
+
+// laneItem is a delivery queued on a lane. A delivery queued on several
+// lanes shares one join between them.
+type laneItem struct {
+	msg  queue.Delivery
+	join *laneJoin
+}
+
+// laneJoin holds back a delivery queued on several lanes until every one of
+// them has reached it. The first lane processes it while the others wait.
+type laneJoin struct {
+	arrived sync.WaitGroup
+	done    chan struct{}
+	lead    chan laneItem
+}
+
+// newLaneDispatcher starts n lanes buffering up to size deliveries each,
+// each running process on its deliveries one at a time
+func newLaneDispatcher(n, size int, process func(queue.Delivery)) *laneDispatcher {
+	d := &laneDispatcher{lanes: make([]chan laneItem, n)}
+	for i := range d.lanes {
+		lane := make(chan laneItem, size)
+		d.lanes[i] = lane
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for item := range lane {
+				switch {
+				case item.join == nil:
+					process(item.msg)
+				case item.join.lead == lane:
+					item.join.arrived.Wait()
+					process(item.msg)
+					close(item.join.done)
+				default:
+					item.join.arrived.Done()
+					<-item.join.done
+				}
+			}
+		}()
+	}
+	return d
+}
+
+// dispatch queues msg on the lanes of its accounts. It only blocks when one
+// of those lanes already buffers as many deliveries as it can hold.
+func (d *laneDispatcher) dispatch(msg queue.Delivery) {
+	lanes := d.lanesOf(orderingKeys(msg.Body()))
+	if len(lanes) == 1 {
+		lanes[0] <- laneItem{msg: msg}
+		return
+	}
+
+	join := &laneJoin{done: make(chan struct{}), lead: lanes[0]}
+	join.arrived.Add(len(lanes) - 1)
+	for _, lane := range lanes {
+		lane <- laneItem{msg: msg, join: join}
+	}
+}
+
+// lanesOf returns the distinct lanes of keys
+func (d *laneDispatcher) lanesOf(keys []string) []chan laneItem {
+	var lanes []chan laneItem
+	seen := map[int]bool{}
+	for _, key := range keys {
+		i := laneIndex(key, len(d.lanes))
+		if !seen[i] {
+			seen[i] = true
+			lanes = append(lanes, d.lanes[i])
+		}
+	}
+	return lanes
+}
+
+// close stops accepting deliveries and waits for in-flight ones to finish
+func (d *laneDispatcher) close() {
+	for _, lane := range d.lanes {
+		close(lane)
+	}
+	d.wg.Wait()
+}
+
+// orderingKeys identifies the accounts whose messages must stay in order.
+// Messages that fail to decode share a lane; they are dead-lettered anyway.
+func orderingKeys(body []byte) []string {
+	_, msg, err := messages.Decode(body)
+	if err != nil {
+		return []string{""}
+	}
+	return msg.OrderingKeys()
+}
+
+func laneIndex(key string, lanes int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(lanes))
+}
@@ -0,0 +1,231 @@
+// Package worker applies queued requests to the ledger.
+package worker
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Processor applies queued requests using injected repositories
+type Processor struct {
+	Accounts     storage.AccountRepository
+	Transactions storage.TransactionRepository
+	Operations   storage.OperationRepository
+}
+
+// NewProcessor creates a Processor backed by the given repositories
+func NewProcessor(accounts storage.AccountRepository, transactions storage.TransactionRepository, operations storage.OperationRepository) *Processor {
+	return &Processor{Accounts: accounts, Transactions: transactions, Operations: operations}
+}
+
+// ProcessTransaction handles messages from the queue. Every delivery is
+// settled: successes and business failures such as insufficient funds are
+// acked, transient errors are retried with exponential backoff and messages
+// that can never be processed are dead-lettered with the reason.
+func (p *Processor) ProcessTransaction(msg queue.Delivery) {
+	var opID int
+
+	// A panic while processing one message must not take down the worker
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic while processing message: %v\n%s", r, debug.Stack())
+			reason := fmt.Sprintf("panic: %v", r)
+			p.completeOperation(opID, 0, errors.New(reason))
+			msg.DeadLetter(reason)
+		}
+	}()
+
+	// Parse and validate the message; older schema versions are upgraded
+	env, data, err := messages.Decode(msg.Body())
+	if env == nil {
+		log.Println("Failed to parse message:", err)
+		msg.DeadLetter(err.Error())
+		return
+	}
+
+	// Operation tracking is optional so that messages published before it
+	// was introduced are still processed
+	opID = env.OperationID
+	if err != nil {
+		log.Println("Invalid message:", err)
+		p.completeOperation(opID, 0, err)
+		msg.DeadLetter(err.Error())
+		return
+	}
+
+	log.Printf("Processing %s message %s (version %d, correlation %s): %+v", env.Type, env.MessageID, env.Version, env.CorrelationID, data)
+
+	if opID != 0 {
+		if err := p.Operations.MarkOperationProcessing(opID); err != nil {
+			log.Println("Failed to mark operation as processing:", err)
+		}
+	}
+
+	accountID, err := p.applyTransaction(env, data)
+	switch {
+	case err == nil:
+		p.completeOperation(opID, accountID, nil)
+		msg.Ack()
+	case errors.Is(err, messages.ErrInvalid):
+		p.completeOperation(opID, accountID, err)
+		msg.DeadLetter(err.Error())
+	case isBusinessFailure(err):
+		p.completeOperation(opID, accountID, err)
+		msg.Ack()
+	case msg.RetryCount() >= queue.MaxRetries:
+		p.completeOperation(opID, accountID, err)
+		msg.DeadLetter(err.Error())
+	default:
+		msg.Retry(err.Error())
+	}
+}
+
+// applyTransaction carries out a decoded message and returns the account it
+// applied to. Duplicate requests are reported as success.
+func (p *Processor) applyTransaction(env *messages.Envelope, data messages.Message) (int, error) {
+	// The outbox relay delivers at least once, so every tracked operation is
+	// deduplicated. Each client idempotency key maps to a single operation.
+	dedupeKey := env.IdempotencyKey
+	if env.OperationID != 0 {
+		dedupeKey = fmt.Sprintf("operation:%d", env.OperationID)
+	}
+
+	switch data := data.(type) {
+	case messages.AccountCreation:
+		// Create account
+		accountID, err := p.Accounts.CreateAccount(data.CustomerID, data.Name, data.ProductCode, data.Currency, data.Balance, dedupeKey)
+		if errors.Is(err, storage.ErrDuplicateRequest) {
+			log.Println("Skipping already processed request:", dedupeKey)
+			err = nil
+		} else if err != nil {
+			log.Println("Account creation failed:", err)
+		} else {
+			log.Println("Account created successfully")
+			p.Transactions.LogTransaction(accountID, data.Balance, "account_creation")
+		}
+		return accountID, err
+	case messages.Deposit:
+		// Deposit funds
+		err := p.checkCurrency(data.AccountID, data.Currency)
+		if err == nil {
+			err = p.Accounts.UpdateBalance(data.AccountID, data.Amount, "deposit", dedupeKey)
+		}
+		if errors.Is(err, storage.ErrDuplicateRequest) {
+			log.Println("Skipping already processed request:", dedupeKey)
+			err = nil
+		} else if err != nil {
+			log.Println("Deposit failed:", err)
+		} else {
+			log.Println("Deposit successful")
+			p.Transactions.LogTransaction(data.AccountID, data.Amount, "deposit")
+		}
+		return data.AccountID, err
+	case messages.Withdraw:
+		// Withdraw funds. The balance is checked under a row lock inside
+		// UpdateBalance so concurrent withdrawals cannot overdraw the account
+		err := p.checkCurrency(data.AccountID, data.Currency)
+		if err == nil {
+			err = p.Accounts.UpdateBalance(data.AccountID, data.Amount, "withdraw", dedupeKey)
+		}
+		if errors.Is(err, storage.ErrDuplicateRequest) {
+			log.Println("Skipping already processed request:", dedupeKey)
+			err = nil
+		} else if err != nil {
+			log.Println("Withdrawal failed:", err)
+		} else {
+			log.Println("Withdrawal successful")
+			p.Transactions.LogTransaction(data.AccountID, data.Amount, "withdraw")
+		}
+		return data.AccountID, err
+	case messages.Transfer:
+		// Move funds between two accounts atomically
+		err := p.Accounts.Transfer(data.FromAccountID, data.ToAccountID, data.Amount, dedupeKey)
+		if errors.Is(err, storage.ErrDuplicateRequest) {
+			log.Println("Skipping already processed request:", dedupeKey)
+			err = nil
+		} else if err != nil {
+			log.Println("Transfer failed:", err)
+		} else {
+			log.Println("Transfer successful")
+			p.Transactions.LogTransaction(data.FromAccountID, data.Amount, "transfer_out")
+			p.Transactions.LogTransaction(data.ToAccountID, data.Amount, "transfer_in")
+		}
+		return data.FromAccountID, err
+	case messages.Reversal:
+		// Post a compensating entry for a deposit or withdrawal
+		reversal, err := p.Transactions.ReverseTransaction(data.TransactionID, data.Reason, data.Actor, dedupeKey)
+		if errors.Is(err, storage.ErrDuplicateRequest) {
+			log.Println("Skipping already processed request:", dedupeKey)
+			err = nil
+		} else if err != nil {
+			log.Println("Reversal failed:", err)
+		} else {
+			log.Printf("Transaction %d reversed by %s: %s", data.TransactionID, data.Actor, data.Reason)
+			p.Transactions.LogTransaction(reversal.AccountID, reversal.Amount, "reversal")
+		}
+		return data.AccountID, err
+	default:
+		// Decode only returns the types above
+		return 0, fmt.Errorf("%w: unhandled message type %q", messages.ErrInvalid, env.Type)
+	}
+}
+
+// checkCurrency fails with storage.ErrCurrencyMismatch when a currency is
+// given and the account holds another. An account's currency never changes,
+// so it is safe to check before the balance update.
+func (p *Processor) checkCurrency(accountID int, code string) error {
+	if code == "" {
+		return nil
+	}
+	acc, err := p.Accounts.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if acc.Currency != code {
+		return fmt.Errorf("%w: account %d holds %s, not %s", storage.ErrCurrencyMismatch, accountID, acc.Currency, code)
+	}
+	return nil
+}
+
+// isBusinessFailure reports whether err is an expected rejection of the
+// request itself rather than a fault worth retrying
+func isBusinessFailure(err error) bool {
+	if errors.Is(err, storage.ErrInsufficientFunds) || errors.Is(err, storage.ErrNotFound) ||
+		errors.Is(err, storage.ErrAccountExists) || errors.Is(err, storage.ErrNotReversible) ||
+		errors.Is(err, storage.ErrAlreadyReversed) || errors.Is(err, storage.ErrAccountNotActive) ||
+		errors.Is(err, storage.ErrProductRule) || errors.Is(err, storage.ErrCurrencyMismatch) ||
+		errors.Is(err, storage.ErrInvalidAmount) {
+		return true
+	}
+
+	// Integrity constraint violations (class 23) and data exceptions
+	// (class 22) will fail the same way on every attempt
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "23") || strings.HasPrefix(pgErr.Code, "22")
+	}
+	return false
+}
+
+// completeOperation records the outcome of a tracked operation
+func (p *Processor) completeOperation(opID int, accountID int, err error) {
+	if opID == 0 {
+		return
+	}
+	if err != nil {
+		err = p.Operations.MarkOperationFailed(opID, err.Error())
+	} else {
+		err = p.Operations.MarkOperationSucceeded(opID, accountID)
+	}
+	if err != nil {
+		log.Println("Failed to update operation status:", err)
+	}
+}
@@ -1,7 +1,6 @@
 package tests
 
 import (
-	"banking-ledger-service/internal/handlers"
 	"banking-ledger-service/internal/models"
 	"banking-ledger-service/tests/mocks"
 	"bytes"
@@ -20,22 +19,18 @@ func TestCreateAccount_Success(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	// Mock storage method
-	mockDB.On("GetAccount", mock.Anything).Return(nil, nil)                 // Account does not exist
-	mockDB.On("CreateAccount", mock.Anything, mock.Anything).Return(1, nil) // Account creation succeeds
-
 	// Mock queue
-	mockQueue.On("PublishMessage", mock.Anything).Return(nil)
+	mockQueue.On("Publish", "account_creation", "", mock.Anything).Return(&models.Operation{ID: 1, Type: "account_creation", Status: models.OperationQueued}, true, nil)
 
 	// Prepare request
-	account := models.Account{Name: "John Doe", Balance: 1000.00}
+	account := models.Account{Name: "John Doe", Balance: 100000}
 	reqBody, _ := json.Marshal(account)
 
 	req := httptest.NewRequest("POST", "/create-account", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.CreateAccount(rec, req)
+	newTestHandler(mockDB, mockQueue).CreateAccount(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
 	mockDB.AssertExpectations(t)
@@ -45,15 +40,16 @@ func TestCreateAccount_Success(t *testing.T) {
 func TestCreateAccount_AlreadyExists(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 
-	// Mock account already exists
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 500}, nil)
+	// Mock the customer already holding an account by that name
+	mockDB.On("ListCustomerAccounts", 3).Return([]models.Account{{ID: 1, Name: "John Doe"}}, nil)
 
-	reqBody, _ := json.Marshal(models.Account{Name: "John Doe", Balance: 1000.00})
+	customerID := 3
+	reqBody, _ := json.Marshal(models.Account{CustomerID: &customerID, Name: "John Doe", Balance: 100000})
 	req := httptest.NewRequest("POST", "/create-account", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.CreateAccount(rec, req)
+	newTestHandler(mockDB, nil).CreateAccount(rec, req)
 
 	assert.Equal(t, http.StatusConflict, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Account already exists")
@@ -65,7 +61,7 @@ func TestCreateAccount_InvalidPayload(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/create-account", bytes.NewReader([]byte("{invalid json}")))
 	rec := httptest.NewRecorder()
 
-	handlers.CreateAccount(rec, req)
+	newTestHandler(nil, nil).CreateAccount(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Invalid request")
@@ -75,18 +71,15 @@ func TestCreateAccount_QueueFailure(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	mockDB.On("GetAccount", mock.Anything).Return(nil, nil)                 // Account does not exist
-	mockDB.On("CreateAccount", mock.Anything, mock.Anything).Return(1, nil) // Account creation succeeds
-
 	// Simulate queue failure
-	mockQueue.On("PublishMessage", mock.Anything).Return(errors.New("queue failure"))
+	mockQueue.On("Publish", "account_creation", "", mock.Anything).Return(nil, false, errors.New("queue failure"))
 
-	reqBody, _ := json.Marshal(models.Account{Name: "John Doe", Balance: 1000.00})
+	reqBody, _ := json.Marshal(models.Account{Name: "John Doe", Balance: 100000})
 	req := httptest.NewRequest("POST", "/create-account", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.CreateAccount(rec, req)
+	newTestHandler(mockDB, mockQueue).CreateAccount(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Failed to queue account creation")
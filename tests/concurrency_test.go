@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectTestDB points storage.DB at the Postgres database named by
+// TEST_DATABASE_URL, which must already have db_init/schema.sql applied.
+// Tests needing a real database are skipped when it is unset.
+func connectTestDB(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	require.NoError(t, pool.Ping(context.Background()))
+
+	previous := storage.DB
+	storage.DB = pool
+	t.Cleanup(func() {
+		storage.DB = previous
+		pool.Close()
+	})
+}
+
+func TestConcurrentWithdrawals_NoOverdraft(t *testing.T) {
+	connectTestDB(t)
+
+	const (
+		opening     = models.Money(10000) // 100.00
+		withdrawal  = models.Money(1000)  // 10.00
+		concurrency = 50
+	)
+
+	name := fmt.Sprintf("stress-%d", time.Now().UnixNano())
+	accountID, err := storage.CreateAccount(nil, name, "", "", opening, "")
+	require.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		rejected  int
+	)
+	start := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			err := storage.UpdateBalance(accountID, withdrawal, "withdraw", "")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, storage.ErrInsufficientFunds):
+				rejected++
+			default:
+				t.Errorf("unexpected withdrawal error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int(opening/withdrawal), succeeded)
+	assert.Equal(t, concurrency-succeeded, rejected)
+
+	account, err := storage.GetAccount(accountID)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(0), account.Balance)
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/currency"
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyCheckAmount(t *testing.T) {
+	for _, tc := range []struct {
+		code    string
+		amount  models.Money
+		allowed bool
+	}{
+		{"USD", 1, true},
+		{"EUR", 12345, true},
+		{"JPY", 500, true},
+		{"JPY", 550, false},
+		{"KRW", 1, false},
+		{"KWD", 100, false},
+		{"usd", 100, false},
+		{"", 100, false},
+	} {
+		err := currency.CheckAmount(tc.code, tc.amount)
+		assert.Equal(t, tc.allowed, err == nil, "%s %d: %v", tc.code, tc.amount, err)
+	}
+
+	assert.Equal(t, models.Money(1), currency.Unit("USD"))
+	assert.Equal(t, models.Money(100), currency.Unit("JPY"))
+	assert.Equal(t, models.Money(200), currency.Round("JPY", 150))
+	assert.Equal(t, models.Money(100), currency.Round("JPY", 149))
+	assert.Equal(t, models.Money(149), currency.Round("USD", 149))
+}
+
+func TestMemoryStore_CurrencyPrecision(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+
+	_, err := store.CreateAccount(nil, "aiko", "", "KWD", 0, "")
+	assert.ErrorIs(t, err, storage.ErrInvalidAmount)
+	_, err = store.CreateAccount(nil, "aiko", "", "JPY", 150, "")
+	assert.ErrorIs(t, err, storage.ErrInvalidAmount)
+
+	id, err := store.CreateAccount(nil, "aiko", "", "JPY", 100000, "")
+	require.NoError(t, err)
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, "JPY", acc.Currency)
+
+	// Yen have no fractions
+	assert.ErrorIs(t, store.UpdateBalance(id, 50, "deposit", ""), storage.ErrInvalidAmount)
+	assert.ErrorIs(t, store.UpdateBalance(id, 150, "withdraw", ""), storage.ErrInvalidAmount)
+	_, err = store.PlaceHold(id, 99, "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrInvalidAmount)
+	require.NoError(t, store.UpdateBalance(id, 500, "deposit", ""))
+
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "deposit", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "JPY", history[0].Currency)
+}
+
+func TestMemoryStore_CrossCurrencyTransfer(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	usd, err := store.CreateAccount(nil, "bea", "", "", 10000, "")
+	require.NoError(t, err)
+	eur, err := store.CreateAccount(nil, "cid", "", "EUR", 10000, "")
+	require.NoError(t, err)
+	other, err := store.CreateAccount(nil, "dov", "", "EUR", 0, "")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, store.Transfer(usd, eur, 100, ""), storage.ErrCurrencyMismatch)
+	require.NoError(t, store.Transfer(eur, other, 100, ""))
+
+	acc, _ := store.GetAccount(usd)
+	assert.Equal(t, currency.Default, acc.Currency)
+	assert.Equal(t, models.Money(10000), acc.Balance)
+}
+
+func TestDeposit_CurrencyMismatch(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD"}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/deposit", bytes.NewBufferString(`{"account_id": 1, "amount": 10, "currency": "EUR"}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Deposit(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Account holds USD, not EUR")
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWithdraw_FractionalYen(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountActive, ProductCode: products.Checking, Currency: "JPY", Balance: 100000, AvailableBalance: 100000}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/withdraw", bytes.NewBufferString(`{"account_id": 1, "amount": 10.5}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "JPY amounts must be whole")
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateAccount_PublishesCurrency(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockQueue.On("Publish", "account_creation", "", mock.Anything).Return(&models.Operation{ID: 1, Status: models.OperationQueued}, true, nil)
+
+	req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(`{"name": "eve", "currency": "GBP", "balance": 250}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).CreateAccount(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	env := mockQueue.Calls[0].Arguments.Get(2).(*messages.Envelope)
+	var msg messages.AccountCreation
+	require.NoError(t, json.Unmarshal(env.Payload, &msg))
+	assert.Equal(t, "GBP", msg.Currency)
+
+	req = httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(`{"name": "eve", "currency": "KWD", "balance": 250}`))
+	rec = httptest.NewRecorder()
+
+	newTestHandler(nil, nil).CreateAccount(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProcessTransaction_CurrencyMismatchFailsOperation(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 8).Return(nil)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Currency: "USD"}, nil)
+	err := fmt.Errorf("%w: account 1 holds USD, not EUR", storage.ErrCurrencyMismatch)
+	mockDB.On("MarkOperationFailed", 8, err.Error()).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "deposit", "operation_id": 8, "account_id": 1, "amount": 500, "currency": "EUR"}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	msg.AssertExpectations(t)
+}
+
+func TestPostgres_SystemAccountsPerCurrency(t *testing.T) {
+	connectTestDB(t)
+
+	id, err := storage.CreateAccount(nil, fmt.Sprintf("eur-%d", time.Now().UnixNano()), "", "EUR", 0, "")
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateBalance(id, 2500, "deposit", ""))
+
+	// The deposit is funded from the euro cash-in account, not the dollar one
+	var name, code string
+	err = storage.DB.QueryRow(context.Background(), `
+		SELECT a.name, a.currency FROM postings p JOIN accounts a ON a.id = p.account_id
+		WHERE p.entry_id = (SELECT entry_id FROM transactions WHERE account_id = $1 AND type = 'deposit')
+		AND p.account_id <> $1`, id).Scan(&name, &code)
+	require.NoError(t, err)
+	assert.Equal(t, storage.CashInAccount+":EUR", name)
+	assert.Equal(t, "EUR", code)
+}
+
+func TestMemoryBackend_YenRoundTrip(t *testing.T) {
+	server := newMemoryServer(t)
+
+	acc := submit(t, server, "/accounts/create", `{"name": "aiko", "currency": "JPY", "balance": 1500}`)
+	require.Equal(t, models.OperationSucceeded, acc.Status)
+	op := submit(t, server, "/transactions/deposit", fmt.Sprintf(`{"account_id": %d, "amount": 500}`, *acc.AccountID))
+	require.Equal(t, models.OperationSucceeded, op.Status)
+
+	// Yen come back whole, exactly as they were sent
+	resp, err := http.Get(fmt.Sprintf("%s/accounts/balance?id=%d", server.URL, *acc.AccountID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var account map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&account))
+	assert.Equal(t, "2000", string(account["balance"]))
+	assert.Equal(t, "2000", string(account["available_balance"]))
+	assert.Equal(t, models.Money(200000), balanceOf(t, server, *acc.AccountID))
+
+	resp, err = http.Get(fmt.Sprintf("%s/accounts/%d/transactions?sort=asc", server.URL, *acc.AccountID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var page struct {
+		Transactions []map[string]json.RawMessage `json:"transactions"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Len(t, page.Transactions, 2)
+	assert.Equal(t, "1500", string(page.Transactions[0]["amount"]))
+	assert.Equal(t, "500", string(page.Transactions[1]["amount"]))
+	assert.Equal(t, "2000", string(page.Transactions[1]["balance_after"]))
+}
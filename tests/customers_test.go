@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_CustomerAccounts(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	customer, err := store.CreateCustomer(models.Customer{LegalName: "Quinn Ruiz", Email: "quinn@example.com", DateOfBirth: "1990-04-01"})
+	require.NoError(t, err)
+
+	checking, err := store.CreateAccount(&customer.ID, "checking", "", "", 1000, "")
+	require.NoError(t, err)
+	savings, err := store.CreateAccount(&customer.ID, "savings", "", "", 0, "")
+	require.NoError(t, err)
+
+	// Names are unique per customer, not across customers
+	_, err = store.CreateAccount(&customer.ID, "checking", "", "", 0, "")
+	assert.ErrorIs(t, err, storage.ErrAccountExists)
+	_, err = store.CreateAccount(nil, "checking", "", "", 0, "")
+	require.NoError(t, err)
+	missing := 99
+	_, err = store.CreateAccount(&missing, "checking", "", "", 0, "")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	accounts, err := store.ListCustomerAccounts(customer.ID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, checking, accounts[0].ID)
+	assert.Equal(t, savings, accounts[1].ID)
+	assert.Equal(t, customer.ID, *accounts[0].CustomerID)
+	assert.Equal(t, models.Money(1000), accounts[0].AvailableBalance)
+
+	assert.ErrorIs(t, store.DeleteCustomer(customer.ID), storage.ErrCustomerHasAccounts)
+}
+
+func TestMemoryStore_AccountWithoutCustomer(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "Sam Tate", "", "", 0, "")
+	require.NoError(t, err)
+
+	acc, err := store.GetAccount(id)
+	require.NoError(t, err)
+	require.NotNil(t, acc.CustomerID)
+	customer, err := store.GetCustomer(*acc.CustomerID)
+	require.NoError(t, err)
+	assert.Equal(t, "Sam Tate", customer.LegalName)
+}
+
+func TestMemoryStore_UpdateAndDeleteCustomer(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	customer, err := store.CreateCustomer(models.Customer{LegalName: "Uma Vance", Phone: "555-0100"})
+	require.NoError(t, err)
+
+	updated, err := store.UpdateCustomer(models.Customer{ID: customer.ID, LegalName: "Uma Vance-Wolfe", Address: "1 Main St"})
+	require.NoError(t, err)
+	assert.Equal(t, "Uma Vance-Wolfe", updated.LegalName)
+	assert.Empty(t, updated.Phone)
+	assert.Equal(t, customer.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, store.DeleteCustomer(customer.ID))
+	_, err = store.GetCustomer(customer.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.UpdateCustomer(models.Customer{ID: customer.ID, LegalName: "Uma"})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestCreateCustomer_Validation(t *testing.T) {
+	for _, body := range []string{
+		`{"email": "no-name@example.com"}`,
+		`{"legal_name": "Val", "email": "not-an-email"}`,
+		`{"legal_name": "Val", "date_of_birth": "01/02/1990"}`,
+		`{"legal_name": "Val", "date_of_birth": "2999-01-01"}`,
+	} {
+		req := httptest.NewRequest("POST", "/customers", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		newTestHandler(nil, nil).CreateCustomer(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
+
+func TestCreateAccount_CustomerAccountExists(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("ListCustomerAccounts", 3).Return([]models.Account{{ID: 8, Name: "savings"}}, nil)
+
+	req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(`{"customer_id": 3, "name": "savings", "balance": 10}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CreateAccount(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestCreateAccount_SameNameForTwoCustomers(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("ListCustomerAccounts", 3).Return([]models.Account{{ID: 8, Name: "savings"}}, nil)
+	mockDB.On("ListCustomerAccounts", 4).Return([]models.Account{{ID: 9, Name: "savings"}}, nil)
+	mockDB.On("ListCustomerAccounts", 5).Return([]models.Account{{ID: 10, Name: "checking"}}, nil)
+	mockQueue.On("Publish", "account_creation", "", mock.Anything).Return(&models.Operation{ID: 1, Status: models.OperationQueued}, true, nil)
+
+	// Other customers' accounts named savings do not block a new one, with
+	// or without a customer
+	for _, body := range []string{
+		`{"customer_id": 5, "name": "savings", "balance": 10}`,
+		`{"name": "savings", "balance": 10}`,
+	} {
+		req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+
+		newTestHandler(mockDB, mockQueue).CreateAccount(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, body)
+	}
+	mockQueue.AssertNumberOfCalls(t, "Publish", 2)
+	mockDB.AssertNotCalled(t, "ListCustomerAccounts", 3)
+	mockDB.AssertNotCalled(t, "ListCustomerAccounts", 4)
+}
+
+func TestCreateAccount_UnknownCustomer(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("ListCustomerAccounts", 3).Return(nil, storage.ErrNotFound)
+
+	req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(`{"customer_id": 3, "name": "savings", "balance": 10}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CreateAccount(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Customer not found")
+}
+
+func TestDeleteCustomer_HoldsAccounts(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("DeleteCustomer", 3).Return(storage.ErrCustomerHasAccounts)
+
+	req := httptest.NewRequest("DELETE", "/customers/3", nil)
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).DeleteCustomer(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestMemoryBackend_CustomerWithTwoAccounts(t *testing.T) {
+	server := newMemoryServer(t)
+
+	resp, err := http.Post(server.URL+"/customers", "application/json", strings.NewReader(`{"legal_name": "Wes Young"}`))
+	require.NoError(t, err)
+	var customer models.Customer
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&customer))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, name := range []string{"checking", "savings"} {
+		op := submit(t, server, "/accounts/create", fmt.Sprintf(`{"customer_id": %d, "name": %q, "balance": 5}`, customer.ID, name))
+		require.Equal(t, models.OperationSucceeded, op.Status)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/customers/%d/accounts", server.URL, customer.ID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var accounts []models.Account
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accounts))
+	require.Len(t, accounts, 2)
+	assert.Equal(t, "checking", accounts[0].Name)
+	assert.Equal(t, "savings", accounts[1].Name)
+}
@@ -2,7 +2,9 @@ package tests
 
 import (
 	"banking-ledger-service/internal/handlers"
+	"banking-ledger-service/internal/messages"
 	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
 	"banking-ledger-service/tests/mocks"
 	"bytes"
 	"encoding/json"
@@ -20,17 +22,17 @@ func TestDeposit_Success(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 1000.00}, nil)
-	mockQueue.On("PublishMessage", mock.Anything).Return(nil)
+	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 100000, AvailableBalance: 100000}, nil)
+	mockQueue.On("Publish", "deposit", "", mock.Anything).Return(&models.Operation{ID: 1, Type: "deposit", Status: models.OperationQueued}, true, nil)
 
-	transaction := models.Transaction{AccountID: 1, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/deposit", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Deposit(rec, req)
+	newTestHandler(mockDB, mockQueue).Deposit(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Deposit request sent to queue")
@@ -40,28 +42,28 @@ func TestDeposit_Success(t *testing.T) {
 }
 
 func TestDeposit_NegativeAmount(t *testing.T) {
-	transaction := models.Transaction{AccountID: 1, Amount: -500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: -50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/deposit", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Deposit(rec, req)
+	newTestHandler(nil, nil).Deposit(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Deposit amount must be greater than zero")
 }
 
 func TestDeposit_ZeroAmount(t *testing.T) {
-	transaction := models.Transaction{AccountID: 1, Amount: 0.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 0}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/deposit", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Deposit(rec, req)
+	newTestHandler(nil, nil).Deposit(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Deposit amount must be greater than zero")
@@ -72,14 +74,14 @@ func TestDeposit_NonExistentAccount(t *testing.T) {
 
 	mockDB.On("GetAccount", mock.Anything).Return(nil, errors.New("account not found"))
 
-	transaction := models.Transaction{AccountID: 99, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 99, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/deposit", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Deposit(rec, req)
+	newTestHandler(mockDB, nil).Deposit(rec, req)
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Account not found")
@@ -91,17 +93,17 @@ func TestDeposit_QueueFailure(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 1000.00}, nil)
-	mockQueue.On("PublishMessage", mock.Anything).Return(errors.New("queue failure"))
+	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 100000, AvailableBalance: 100000}, nil)
+	mockQueue.On("Publish", "deposit", "", mock.Anything).Return(nil, false, errors.New("queue failure"))
 
-	transaction := models.Transaction{AccountID: 1, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/deposit", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Deposit(rec, req)
+	newTestHandler(mockDB, mockQueue).Deposit(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Failed to queue deposit transaction")
@@ -109,3 +111,27 @@ func TestDeposit_QueueFailure(t *testing.T) {
 	mockDB.AssertExpectations(t)
 	mockQueue.AssertExpectations(t)
 }
+
+func TestDeposit_PublishesTypedEnvelope(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 100000, AvailableBalance: 100000}, nil)
+	mockQueue.On("Publish", "deposit", "", mock.MatchedBy(func(env *messages.Envelope) bool {
+		return env.Version == messages.CurrentVersion &&
+			env.Type == messages.TypeDeposit &&
+			env.MessageID != "" &&
+			env.CorrelationID == "req-42" &&
+			string(env.Payload) == `{"account_id":1,"amount":500.00}`
+	})).Return(&models.Operation{ID: 1, Type: "deposit", Status: models.OperationQueued}, true, nil)
+
+	req := httptest.NewRequest("POST", "/deposit", bytes.NewBufferString(`{"account_id": 1, "amount": 500}`))
+	req.Header.Set(handlers.CorrelationIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Deposit(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "req-42", rec.Header().Get(handlers.CorrelationIDHeader))
+	mockQueue.AssertExpectations(t)
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/fees"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateFees(t *testing.T) {
+	maxFee := models.Money(500)
+	rules := []models.FeeRule{
+		{ID: 1, Name: "atm", TransactionType: "withdraw", FreePerMonth: 3, FlatFee: 200, Active: true},
+		{ID: 2, Name: "large withdrawal", TransactionType: "withdraw", MinAmount: 100000, RateBps: 100, MaxFee: &maxFee, Active: true},
+		{ID: 3, Name: "business deposit", TransactionType: "deposit", AccountType: "business", RateBps: 25, Active: true},
+		{ID: 4, Name: "retired", TransactionType: "withdraw", FlatFee: 100},
+	}
+
+	// Within the free allowance and below the threshold nothing is charged
+	assert.Empty(t, fees.Evaluate(rules, "withdraw", "standard", 5000, 2))
+
+	// The fourth withdrawal of the month pays the flat fee
+	charges := fees.Evaluate(rules, "withdraw", "standard", 5000, 3)
+	assert.Equal(t, []models.FeeCharge{{RuleID: 1, Name: "atm", Amount: 200}}, charges)
+
+	// 1% of 2000.00 is capped at 5.00
+	charges = fees.Evaluate(rules, "withdraw", "standard", 200000, 0)
+	assert.Equal(t, []models.FeeCharge{{RuleID: 2, Name: "large withdrawal", Amount: 500}}, charges)
+	charges = fees.Evaluate(rules, "withdraw", "standard", 100000, 5)
+	assert.Equal(t, models.Money(700), fees.Total(charges))
+
+	// Account types select their own rules; 0.25% of 100.10 rounds to 0.25
+	assert.Empty(t, fees.Evaluate(rules, "deposit", "standard", 10010, 0))
+	charges = fees.Evaluate(rules, "deposit", "business", 10010, 0)
+	assert.Equal(t, []models.FeeCharge{{RuleID: 3, Name: "business deposit", Amount: 25}}, charges)
+}
+
+func TestValidateFeeRule(t *testing.T) {
+	valid := models.FeeRule{Name: "atm", TransactionType: "withdraw", FlatFee: 200}
+	assert.NoError(t, fees.Validate(&valid))
+
+	for _, change := range []func(r *models.FeeRule){
+		func(r *models.FeeRule) { r.Name = "" },
+		func(r *models.FeeRule) { r.TransactionType = "transfer" },
+		func(r *models.FeeRule) { r.FlatFee = -1 },
+		func(r *models.FeeRule) { r.FlatFee = 0 },
+		func(r *models.FeeRule) { r.FreePerMonth = -1 },
+	} {
+		r := valid
+		change(&r)
+		assert.Error(t, fees.Validate(&r))
+	}
+}
+
+func TestMemoryStore_ChargesLinkedFees(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "gina", "", "", 10000, "")
+	require.NoError(t, err)
+	_, err = store.CreateFeeRule(models.FeeRule{Name: "atm", TransactionType: "withdraw", FreePerMonth: 1, FlatFee: 150})
+	require.NoError(t, err)
+
+	require.NoError(t, store.UpdateBalance(id, 1000, "withdraw", ""))
+	preview, err := store.PreviewFees(id, "withdraw", 1000)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(150), fees.Total(preview))
+
+	require.NoError(t, store.UpdateBalance(id, 1000, "withdraw", ""))
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(7850), acc.Balance)
+
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	fee := history[3]
+	assert.Equal(t, "fee", fee.Type)
+	assert.Equal(t, models.Money(150), fee.Amount)
+	assert.Equal(t, "atm", fee.Reason)
+	require.NotNil(t, fee.FeeFor)
+	assert.Equal(t, history[2].ID, *fee.FeeFor)
+
+	// The fee must be covered along with the withdrawal
+	assert.ErrorIs(t, store.UpdateBalance(id, 7800, "withdraw", ""), storage.ErrInsufficientFunds)
+	require.NoError(t, store.UpdateBalance(id, 7700, "withdraw", ""))
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(0), acc.Balance)
+}
+
+func TestPreviewFees(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("PreviewFees", 1, "withdraw", models.Money(25000)).
+		Return([]models.FeeCharge{{RuleID: 1, Name: "atm", Amount: 200}, {RuleID: 2, Name: "large", Amount: 250}}, nil)
+
+	req := httptest.NewRequest("GET", "/fees/preview?account_id=1&type=withdraw&amount=250", nil)
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).PreviewFees(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Fees  []models.FeeCharge `json:"fees"`
+		Total models.Money       `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Fees, 2)
+	assert.Equal(t, models.Money(450), resp.Total)
+	mockDB.AssertExpectations(t)
+}
+
+func TestPreviewFees_InvalidType(t *testing.T) {
+	req := httptest.NewRequest("GET", "/fees/preview?account_id=1&type=transfer&amount=250", nil)
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).PreviewFees(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCreateFeeRule_Invalid(t *testing.T) {
+	req := httptest.NewRequest("POST", "/fee-rules", bytes.NewBufferString(`{"name": "atm", "transaction_type": "withdraw"}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).CreateFeeRule(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSetAccountType_NotFound(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("SetAccountType", 9, "business").Return(storage.ErrNotFound)
+
+	req := httptest.NewRequest("PUT", "/accounts/9/type", bytes.NewBufferString(`{"account_type": "business"}`))
+	req.SetPathValue("id", "9")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).SetAccountType(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
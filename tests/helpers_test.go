@@ -0,0 +1,18 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/handlers"
+	"banking-ledger-service/tests/mocks"
+)
+
+// newTestHandler wires a handler to the given mocks. A nil mock is replaced by
+// one without expectations, so any unexpected call fails the test.
+func newTestHandler(mockDB *mocks.MockDB, mockQueue *mocks.MockQueue) *handlers.Handler {
+	if mockDB == nil {
+		mockDB = new(mocks.MockDB)
+	}
+	if mockQueue == nil {
+		mockQueue = new(mocks.MockQueue)
+	}
+	return handlers.New(mockDB, mockDB, mockDB, mockDB, mockDB, mockDB, mockDB, mockDB, mockQueue)
+}
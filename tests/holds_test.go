@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceHold_Success(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	expiresAt := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.On("PlaceHold", 1, models.Money(2500), "auth-1", expiresAt).
+		Return(&models.Hold{ID: 3, AccountID: 1, Amount: 2500, Reference: "auth-1", Status: models.HoldActive, ExpiresAt: expiresAt}, nil)
+
+	req := httptest.NewRequest("POST", "/accounts/1/holds", bytes.NewBufferString(`{"amount": 25, "reference": "auth-1", "expires_at": "2099-01-01T00:00:00Z"}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).PlaceHold(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var hold models.Hold
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hold))
+	assert.Equal(t, 3, hold.ID)
+	assert.Equal(t, models.HoldActive, hold.Status)
+	mockDB.AssertExpectations(t)
+}
+
+func TestPlaceHold_InsufficientFunds(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("PlaceHold", 1, models.Money(2500), "", mock.Anything).Return(nil, storage.ErrInsufficientFunds)
+
+	req := httptest.NewRequest("POST", "/accounts/1/holds", bytes.NewBufferString(`{"amount": 25}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).PlaceHold(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Insufficient funds")
+}
+
+func TestPlaceHold_ExpiryInPast(t *testing.T) {
+	req := httptest.NewRequest("POST", "/accounts/1/holds", bytes.NewBufferString(`{"amount": 25, "expires_at": "2000-01-01T00:00:00Z"}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).PlaceHold(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Hold expiry must be in the future")
+}
+
+func TestCaptureHold_Partial(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("CaptureHold", 3, models.Money(1000)).
+		Return(&models.Hold{ID: 3, AccountID: 1, Amount: 2500, CapturedAmount: 1000, Status: models.HoldCaptured}, nil)
+	mockDB.On("LogTransaction", 1, models.Money(1000), "capture").Return()
+
+	req := httptest.NewRequest("POST", "/holds/3/capture", bytes.NewBufferString(`{"amount": 10}`))
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CaptureHold(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"captured_amount":10.00`)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCaptureHold_NotActive(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("CaptureHold", 3, models.Money(0)).Return(nil, storage.ErrHoldNotActive)
+
+	req := httptest.NewRequest("POST", "/holds/3/capture", nil)
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CaptureHold(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestReleaseHold_NotFound(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("ReleaseHold", 9).Return(nil, storage.ErrNotFound)
+
+	req := httptest.NewRequest("POST", "/holds/9/release", nil)
+	req.SetPathValue("id", "9")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).ReleaseHold(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWithdraw_RespectsHolds(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 10000, AvailableBalance: 2000}, nil)
+
+	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBufferString(`{"account_id": 1, "amount": 50}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).Withdraw(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Insufficient funds")
+}
+
+func TestMemoryStore_Holds(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "dave", "", "", 10000, "")
+	require.NoError(t, err)
+
+	hold, err := store.PlaceHold(id, 6000, "auth-1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(10000), acc.Balance)
+	assert.Equal(t, models.Money(4000), acc.AvailableBalance)
+
+	// Held funds can be neither withdrawn nor held twice
+	assert.ErrorIs(t, store.UpdateBalance(id, 5000, "withdraw", ""), storage.ErrInsufficientFunds)
+	_, err = store.PlaceHold(id, 5000, "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+
+	// A partial capture releases the remainder
+	_, err = store.CaptureHold(hold.ID, 7000)
+	assert.ErrorIs(t, err, storage.ErrCaptureExceedsHold)
+	hold, err = store.CaptureHold(hold.ID, 2500)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldCaptured, hold.Status)
+
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(7500), acc.Balance)
+	assert.Equal(t, models.Money(7500), acc.AvailableBalance)
+
+	_, err = store.ReleaseHold(hold.ID)
+	assert.ErrorIs(t, err, storage.ErrHoldNotActive)
+
+	// Expired holds no longer reserve funds
+	expiring, err := store.PlaceHold(id, 7500, "", time.Now().Add(10*time.Millisecond))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(7500), acc.AvailableBalance)
+	expiring, err = store.GetHold(expiring.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldExpired, expiring.Status)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgres_ReplayedKeyLeavesBalanceUntouched(t *testing.T) {
+	connectTestDB(t)
+
+	suffix := time.Now().UnixNano()
+	id, err := storage.CreateAccount(nil, fmt.Sprintf("idem-%d", suffix), "", "", 1000, "")
+	require.NoError(t, err)
+	other, err := storage.CreateAccount(nil, fmt.Sprintf("idem-other-%d", suffix), "", "", 0, "")
+	require.NoError(t, err)
+
+	key := fmt.Sprintf("deposit-%d", suffix)
+	require.NoError(t, storage.UpdateBalance(id, 500, "deposit", key))
+	assert.ErrorIs(t, storage.UpdateBalance(id, 500, "deposit", key), storage.ErrDuplicateRequest)
+
+	key = fmt.Sprintf("transfer-%d", suffix)
+	require.NoError(t, storage.Transfer(id, other, 300, key))
+	assert.ErrorIs(t, storage.Transfer(id, other, 300, key), storage.ErrDuplicateRequest)
+
+	acc, err := storage.GetAccount(id)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1200), acc.Balance)
+	acc, err = storage.GetAccount(other)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(300), acc.Balance)
+
+	history, err := storage.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "deposit", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestPostgres_ReplayedAccountCreationReturnsOriginal(t *testing.T) {
+	connectTestDB(t)
+
+	suffix := time.Now().UnixNano()
+	key := fmt.Sprintf("create-%d", suffix)
+	id, err := storage.CreateAccount(nil, fmt.Sprintf("idem-create-%d", suffix), "", "", 1000, key)
+	require.NoError(t, err)
+
+	again, err := storage.CreateAccount(nil, fmt.Sprintf("idem-create-%d", suffix), "", "", 1000, key)
+	assert.ErrorIs(t, err, storage.ErrDuplicateRequest)
+	assert.Equal(t, id, again)
+
+	acc, err := storage.GetAccount(id)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1000), acc.Balance)
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/interest"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyAccrual(t *testing.T) {
+	flat := &models.InterestProduct{AnnualRateBps: 365, DayCount: models.DayCountActual365}
+	day := utcTime("2026-03-10T00:00:00Z")
+
+	// 3.65% of 1000.00 over a 365 day year is 10 cents a day
+	assert.Equal(t, int64(10*models.AccrualUnitsPerCent), interest.DailyAccrual(flat, 100000, day))
+	assert.Equal(t, int64(0), interest.DailyAccrual(flat, 0, day))
+	assert.Equal(t, int64(0), interest.DailyAccrual(flat, -5000, day))
+
+	// Each tier's rate applies to its band of the balance
+	tiered := &models.InterestProduct{
+		AnnualRateBps: 100,
+		DayCount:      models.DayCountActual360,
+		Tiers:         []models.InterestTier{{MinBalance: 100000, AnnualRateBps: 200}, {MinBalance: 500000, AnnualRateBps: 300}},
+	}
+	// 1% of 1000.00 plus 2% of 1000.00 over 360 days
+	assert.Equal(t, int64(8333333), interest.DailyAccrual(tiered, 200000, day))
+	// Balances below the first tier earn the base rate only
+	assert.Equal(t, int64(2777777), interest.DailyAccrual(tiered, 100000, day))
+}
+
+func TestDailyAccrual_DayCounts(t *testing.T) {
+	product := &models.InterestProduct{AnnualRateBps: 360, DayCount: models.DayCount30360}
+	accrual := func(day string) int64 { return interest.DailyAccrual(product, 100000, utcTime(day)) }
+
+	// 30/360 counts every month as 30 days of 10 cents
+	assert.Equal(t, int64(10*models.AccrualUnitsPerCent), accrual("2026-01-15T00:00:00Z"))
+	assert.Equal(t, int64(0), accrual("2026-01-31T00:00:00Z"))
+	assert.Equal(t, int64(30*models.AccrualUnitsPerCent), accrual("2026-02-28T00:00:00Z"))
+	assert.Equal(t, int64(20*models.AccrualUnitsPerCent), accrual("2028-02-29T00:00:00Z"))
+
+	product = &models.InterestProduct{AnnualRateBps: 366, DayCount: models.DayCountActualActual}
+	assert.Equal(t, int64(10*models.AccrualUnitsPerCent), interest.DailyAccrual(product, 100000, utcTime("2028-06-01T00:00:00Z")))
+	assert.Equal(t, int64(10027397), interest.DailyAccrual(product, 100000, utcTime("2027-06-01T00:00:00Z")))
+}
+
+func TestPeriodStart(t *testing.T) {
+	day := utcTime("2026-08-17T00:00:00Z")
+	assert.Equal(t, day, interest.PeriodStart(models.CompoundDaily, day))
+	assert.Equal(t, utcTime("2026-08-01T00:00:00Z"), interest.PeriodStart(models.CompoundMonthly, day))
+	assert.Equal(t, utcTime("2026-07-01T00:00:00Z"), interest.PeriodStart(models.CompoundQuarterly, day))
+	assert.Equal(t, utcTime("2026-01-01T00:00:00Z"), interest.PeriodStart(models.CompoundAnnually, day))
+}
+
+func TestValidateInterestProduct(t *testing.T) {
+	valid := models.InterestProduct{Name: "savings", AnnualRateBps: 150, DayCount: models.DayCountActual365, Compounding: models.CompoundMonthly}
+	assert.NoError(t, interest.Validate(&valid))
+
+	for _, change := range []func(p *models.InterestProduct){
+		func(p *models.InterestProduct) { p.Name = "" },
+		func(p *models.InterestProduct) { p.AnnualRateBps = -1 },
+		func(p *models.InterestProduct) { p.DayCount = "actual/364" },
+		func(p *models.InterestProduct) { p.Compounding = "weekly" },
+		func(p *models.InterestProduct) {
+			p.Tiers = []models.InterestTier{{MinBalance: 5000, AnnualRateBps: 200}, {MinBalance: 5000, AnnualRateBps: 300}}
+		},
+	} {
+		p := valid
+		change(&p)
+		assert.Error(t, interest.Validate(&p))
+	}
+}
+
+func TestInterestEngine_AccruesAndPostsMonthly(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "erin", "", "", 100000, "")
+	require.NoError(t, err)
+	product, err := store.CreateInterestProduct(models.InterestProduct{
+		Name: "savings", AnnualRateBps: 365, DayCount: models.DayCountActual365, Compounding: models.CompoundMonthly,
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.SetInterestProduct(id, &product.ID, utcTime("2099-01-15T00:00:00Z")))
+
+	engine := &interest.Engine{Interest: store}
+	require.NoError(t, engine.RunThrough(utcTime("2099-03-10T08:00:00Z")))
+
+	// January 15th to March 9th are accrued; only January and February are
+	// posted, 45 days of 10 cents
+	accruals, err := store.ListAccruals(id)
+	require.NoError(t, err)
+	require.Len(t, accruals, 54)
+	assert.Equal(t, utcTime("2099-03-09T00:00:00Z"), accruals[0].Date)
+	assert.Nil(t, accruals[0].TransactionID)
+	assert.NotNil(t, accruals[9].TransactionID)
+
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(100450), acc.Balance)
+
+	// Running again accrues and posts nothing twice
+	require.NoError(t, engine.RunThrough(utcTime("2099-03-10T20:00:00Z")))
+	accruals, _ = store.ListAccruals(id)
+	assert.Len(t, accruals, 54)
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(100450), acc.Balance)
+
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "interest", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.Money(450), history[0].Amount)
+}
+
+func TestInterestEngine_DailyCompounding(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "frank", "", "", 100000, "")
+	require.NoError(t, err)
+	product, err := store.CreateInterestProduct(models.InterestProduct{
+		Name: "daily", AnnualRateBps: 365, DayCount: models.DayCountActual365, Compounding: models.CompoundDaily,
+	})
+	require.NoError(t, err)
+	today := interest.Date(time.Now())
+	require.NoError(t, store.SetInterestProduct(id, &product.ID, today))
+
+	// Each day's interest is posted the next day
+	engine := &interest.Engine{Interest: store}
+	require.NoError(t, engine.RunThrough(today.AddDate(0, 0, 1)))
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(100010), acc.Balance)
+
+	// Removing the product stops accruals but nothing accrued is lost
+	require.NoError(t, store.SetInterestProduct(id, nil, time.Time{}))
+	require.NoError(t, engine.RunThrough(today.AddDate(0, 0, 5)))
+	accruals, _ := store.ListAccruals(id)
+	assert.Len(t, accruals, 1)
+}
+
+func TestCreateInterestProduct_Invalid(t *testing.T) {
+	req := httptest.NewRequest("POST", "/interest-products", bytes.NewBufferString(`{"name": "savings", "annual_rate_bps": 150, "day_count": "actual/365", "compounding": "hourly"}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).CreateInterestProduct(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCreateInterestProduct_Exists(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("CreateInterestProduct", mock.Anything).Return(nil, storage.ErrProductExists)
+
+	req := httptest.NewRequest("POST", "/interest-products", bytes.NewBufferString(`{"name": "savings", "annual_rate_bps": 150, "day_count": "actual/365", "compounding": "monthly", "tiers": [{"min_balance": 1000, "annual_rate_bps": 200}]}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CreateInterestProduct(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSetInterestProduct(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	productID := 2
+	mockDB.On("GetInterestProduct", 2).Return(&models.InterestProduct{ID: 2}, nil)
+	mockDB.On("SetInterestProduct", 1, &productID, mock.Anything).Return(nil)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, InterestProductID: &productID}, nil)
+
+	req := httptest.NewRequest("PUT", "/accounts/1/interest-product", bytes.NewBufferString(`{"product_id": 2}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).SetInterestProduct(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"interest_product_id":2`)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSetInterestProduct_UnknownProduct(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetInterestProduct", 9).Return(nil, storage.ErrNotFound)
+
+	req := httptest.NewRequest("PUT", "/accounts/1/interest-product", bytes.NewBufferString(`{"product_id": 9}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).SetInterestProduct(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/storage"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postRaw writes a journal entry with the given postings straight to the
+// database, bypassing the checks in storage
+func postRaw(t *testing.T, postings map[int]int64) error {
+	ctx := context.Background()
+	tx, err := storage.DB.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	var entryID int
+	require.NoError(t, tx.QueryRow(ctx, "INSERT INTO journal_entries (description) VALUES ('test entry') RETURNING id").Scan(&entryID))
+	for accountID, amount := range postings {
+		_, err := tx.Exec(ctx, "INSERT INTO postings (entry_id, account_id, amount) VALUES ($1, $2, $3)", entryID, accountID, amount)
+		require.NoError(t, err)
+	}
+	return tx.Commit(ctx)
+}
+
+func TestLedger_RejectsUnbalancedEntry(t *testing.T) {
+	connectTestDB(t)
+
+	suffix := time.Now().UnixNano()
+	from, err := storage.CreateAccount(nil, fmt.Sprintf("ledger-a-%d", suffix), "", "", 0, "")
+	require.NoError(t, err)
+	to, err := storage.CreateAccount(nil, fmt.Sprintf("ledger-b-%d", suffix), "", "", 0, "")
+	require.NoError(t, err)
+
+	// The constraint trigger rejects the entry when the transaction commits
+	err = postRaw(t, map[int]int64{from: -100, to: 99})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not balance")
+	err = postRaw(t, map[int]int64{to: 100})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not balance")
+
+	require.NoError(t, postRaw(t, map[int]int64{from: -100, to: 100}))
+}
+
+func TestLedger_EntriesBalance(t *testing.T) {
+	connectTestDB(t)
+
+	id, err := storage.CreateAccount(nil, fmt.Sprintf("ledger-%d", time.Now().UnixNano()), "", "", 10000, "")
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateBalance(id, 2500, "deposit", ""))
+	require.NoError(t, storage.UpdateBalance(id, 1000, "withdraw", ""))
+
+	// Every entry sums to zero and the balance is the sum of the postings
+	var unbalanced int
+	err = storage.DB.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM (SELECT entry_id FROM postings GROUP BY entry_id HAVING SUM(amount) <> 0) e").Scan(&unbalanced)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unbalanced)
+
+	var balance, posted int64
+	err = storage.DB.QueryRow(context.Background(),
+		"SELECT balance, (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE account_id = accounts.id) FROM accounts WHERE id = $1", id).
+		Scan(&balance, &posted)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11500), balance)
+	assert.Equal(t, balance, posted)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/lifecycle"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleNext(t *testing.T) {
+	for _, tc := range []struct {
+		action, from, to string
+	}{
+		{lifecycle.Freeze, models.AccountActive, models.AccountFrozen},
+		{lifecycle.Freeze, models.AccountDormant, models.AccountFrozen},
+		{lifecycle.Unfreeze, models.AccountFrozen, models.AccountActive},
+		{lifecycle.Activate, models.AccountPending, models.AccountActive},
+		{lifecycle.Activate, models.AccountDormant, models.AccountActive},
+		{lifecycle.Close, models.AccountFrozen, models.AccountClosed},
+	} {
+		to, ok := lifecycle.Next(tc.action, tc.from)
+		assert.True(t, ok, "%s %s", tc.action, tc.from)
+		assert.Equal(t, tc.to, to)
+	}
+
+	for _, tc := range [][2]string{
+		{lifecycle.Unfreeze, models.AccountActive},
+		{lifecycle.Activate, models.AccountFrozen},
+		{lifecycle.Freeze, models.AccountClosed},
+		{lifecycle.Close, models.AccountClosed},
+	} {
+		_, ok := lifecycle.Next(tc[0], tc[1])
+		assert.False(t, ok, "%s %s", tc[0], tc[1])
+	}
+}
+
+func TestMemoryStore_FrozenAccountTakesCreditsOnly(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "kim", "", "", 10000, "")
+	require.NoError(t, err)
+	other, err := store.CreateAccount(nil, "lee", "", "", 10000, "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.ChangeAccountStatus(id, lifecycle.Freeze, "suspected fraud", "ops"))
+
+	assert.ErrorIs(t, store.UpdateBalance(id, 100, "withdraw", ""), storage.ErrAccountNotActive)
+	assert.ErrorIs(t, store.Transfer(id, other, 100, ""), storage.ErrAccountNotActive)
+	_, err = store.PlaceHold(id, 100, "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrAccountNotActive)
+	require.NoError(t, store.UpdateBalance(id, 100, "deposit", ""))
+	require.NoError(t, store.Transfer(other, id, 100, ""))
+
+	assert.ErrorIs(t, store.ChangeAccountStatus(id, lifecycle.Activate, "wrong action", "ops"), storage.ErrInvalidTransition)
+	require.NoError(t, store.ChangeAccountStatus(id, lifecycle.Unfreeze, "cleared", "ops"))
+	require.NoError(t, store.UpdateBalance(id, 100, "withdraw", ""))
+
+	events, err := store.ListAccountEvents(id)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.AccountEvent{
+		ID: 1, AccountID: id, FromStatus: models.AccountActive, ToStatus: models.AccountFrozen,
+		Reason: "suspected fraud", Actor: "ops", CreatedAt: events[0].CreatedAt,
+	}, events[0])
+	assert.Equal(t, models.AccountActive, events[1].ToStatus)
+}
+
+func TestMemoryStore_CloseAccount(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "mia", "", "", 2500, "")
+	require.NoError(t, err)
+	other, err := store.CreateAccount(nil, "ned", "", "", 0, "")
+	require.NoError(t, err)
+	start := utcTime("2099-01-01T00:00:00Z")
+	sched, err := store.CreateSchedule(models.Schedule{
+		Type: "transfer", AccountID: other, ToAccountID: &id, Amount: 100, IntervalSeconds: 3600,
+		StartAt: start, NextRunAt: &start, Status: models.ScheduleActive,
+	})
+	require.NoError(t, err)
+
+	// The balance must be zero or paid out
+	assert.ErrorIs(t, store.CloseAccount(id, "customer request", "ops", false), storage.ErrBalanceNotZero)
+	require.NoError(t, store.CloseAccount(id, "customer request", "ops", true))
+
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.AccountClosed, acc.Status)
+	assert.Equal(t, models.Money(0), acc.Balance)
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "payout", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.Money(2500), history[0].Amount)
+
+	sched, _ = store.GetSchedule(sched.ID)
+	assert.Equal(t, models.ScheduleCancelled, sched.Status)
+
+	// Closed accounts take nothing and stay closed
+	assert.ErrorIs(t, store.UpdateBalance(id, 100, "deposit", ""), storage.ErrAccountNotActive)
+	assert.ErrorIs(t, store.Transfer(other, id, 100, ""), storage.ErrAccountNotActive)
+	assert.ErrorIs(t, store.ChangeAccountStatus(id, lifecycle.Activate, "reopen", "ops"), storage.ErrInvalidTransition)
+}
+
+func TestMemoryStore_CloseOverdrawnAccount(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "olga", "", "", 0, "")
+	require.NoError(t, err)
+	require.NoError(t, store.SetOverdraft(id, 1000, nil, time.Now()))
+	require.NoError(t, store.UpdateBalance(id, 500, "withdraw", ""))
+
+	assert.ErrorIs(t, store.CloseAccount(id, "customer request", "ops", true), storage.ErrBalanceNotZero)
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.AccountActive, acc.Status)
+}
+
+func TestMemoryStore_MarkDormant(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "pia", "", "", 1000, "")
+	require.NoError(t, err)
+
+	marked, err := store.MarkDormant(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, marked)
+	marked, err = store.MarkDormant(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+
+	// Dormant accounts take deposits but no withdrawals until activated
+	assert.ErrorIs(t, store.UpdateBalance(id, 100, "withdraw", ""), storage.ErrAccountNotActive)
+	require.NoError(t, store.UpdateBalance(id, 100, "deposit", ""))
+	require.NoError(t, store.ChangeAccountStatus(id, lifecycle.Activate, "customer returned", "ops"))
+	require.NoError(t, store.UpdateBalance(id, 100, "withdraw", ""))
+}
+
+func TestMemoryStore_FrozenAccountPaysNoDepositFees(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	_, err := store.CreateFeeRule(models.FeeRule{Name: "cash deposit", TransactionType: "deposit", FlatFee: 200})
+	require.NoError(t, err)
+	id, err := store.CreateAccount(nil, "quinn", "", "", 10000, "")
+	require.NoError(t, err)
+	require.NoError(t, store.ChangeAccountStatus(id, lifecycle.Freeze, "suspected fraud", "ops"))
+
+	preview, err := store.PreviewFees(id, "deposit", 1000)
+	require.NoError(t, err)
+	assert.Empty(t, preview)
+
+	// The deposit is credited in full and no fee leaves the frozen account
+	require.NoError(t, store.UpdateBalance(id, 1000, "deposit", ""))
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(11000), acc.Balance)
+	fees, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "fee", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, fees)
+
+	// Once unfrozen, deposits pay their fees again
+	require.NoError(t, store.ChangeAccountStatus(id, lifecycle.Unfreeze, "cleared", "ops"))
+	require.NoError(t, store.UpdateBalance(id, 1000, "deposit", ""))
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(11800), acc.Balance)
+}
+
+func TestDeposit_ClosedAccount(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountClosed}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/deposit", bytes.NewBufferString(`{"account_id": 1, "amount": 10}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Deposit(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Account is closed")
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWithdraw_FrozenAccount(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountFrozen, Balance: 10000, AvailableBalance: 10000}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/withdraw", bytes.NewBufferString(`{"account_id": 1, "amount": 10}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestFreezeAccount_RequiresReason(t *testing.T) {
+	req := httptest.NewRequest("POST", "/accounts/1/freeze", bytes.NewBufferString(`{"actor": "ops"}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).FreezeAccount(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCloseAccount_BalanceNotZero(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("CloseAccount", 1, "customer request", "ops", false).Return(storage.ErrBalanceNotZero)
+
+	req := httptest.NewRequest("POST", "/accounts/1/close", bytes.NewBufferString(`{"reason": "customer request", "actor": "ops"}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CloseAccount(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestProcessTransaction_InactiveAccountFailsOperation(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	err := fmt.Errorf("%w: account 1 is frozen", storage.ErrAccountNotActive)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "withdraw", "operation:7").Return(err)
+	mockDB.On("MarkOperationFailed", 7, err.Error()).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "withdraw", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
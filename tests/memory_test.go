@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/handlers"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/internal/worker"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMemoryServer runs the API and an in-process worker on the memory backend
+func newMemoryServer(t *testing.T) *httptest.Server {
+	q := queue.NewMemoryQueue(100)
+	store := storage.NewMemory(q.PublishMessage)
+
+	messages, err := q.ConsumeMessages(0)
+	require.NoError(t, err)
+	go worker.NewProcessor(store, store, store).Run(messages, 4, 100)
+
+	h := handlers.New(store, store, store, store, store, store, store, store, store)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accounts/create", h.CreateAccount)
+	mux.HandleFunc("/accounts/balance", h.GetAccountBalance)
+	mux.HandleFunc("POST /customers", h.CreateCustomer)
+	mux.HandleFunc("GET /customers/{id}/accounts", h.ListCustomerAccounts)
+	mux.HandleFunc("/transactions/deposit", h.Deposit)
+	mux.HandleFunc("/transactions/withdraw", h.Withdraw)
+	mux.HandleFunc("/transactions/transfer", h.Transfer)
+	mux.HandleFunc("POST /transactions/{id}/reverse", h.ReverseTransaction)
+	mux.HandleFunc("GET /accounts/{id}/transactions", h.ListTransactions)
+	mux.HandleFunc("GET /operations/{id}", h.GetOperation)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// submit posts body to path and waits for the queued operation to finish
+func submit(t *testing.T, server *httptest.Server, path, body string) models.Operation {
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var accepted struct {
+		OperationID int `json:"operation_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accepted))
+
+	var op models.Operation
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(fmt.Sprintf("%s/operations/%d", server.URL, accepted.OperationID))
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		json.NewDecoder(resp.Body).Decode(&op)
+		return op.Status == models.OperationSucceeded || op.Status == models.OperationFailed
+	}, 2*time.Second, 10*time.Millisecond)
+	return op
+}
+
+func balanceOf(t *testing.T, server *httptest.Server, id int) models.Money {
+	resp, err := http.Get(fmt.Sprintf("%s/accounts/balance?id=%d", server.URL, id))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var acc models.Account
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&acc))
+	return acc.Balance
+}
+
+func TestMemoryBackend_EndToEnd(t *testing.T) {
+	server := newMemoryServer(t)
+
+	alice := submit(t, server, "/accounts/create", `{"name": "alice", "balance": 100}`)
+	require.Equal(t, models.OperationSucceeded, alice.Status)
+	bob := submit(t, server, "/accounts/create", `{"name": "bob", "balance": 0}`)
+	require.Equal(t, models.OperationSucceeded, bob.Status)
+
+	op := submit(t, server, "/transactions/deposit", fmt.Sprintf(`{"account_id": %d, "amount": "25.50"}`, *alice.AccountID))
+	assert.Equal(t, models.OperationSucceeded, op.Status)
+
+	op = submit(t, server, "/transactions/transfer", fmt.Sprintf(`{"from_account_id": %d, "to_account_id": %d, "amount": 40}`, *alice.AccountID, *bob.AccountID))
+	assert.Equal(t, models.OperationSucceeded, op.Status)
+
+	op = submit(t, server, "/transactions/withdraw", fmt.Sprintf(`{"account_id": %d, "amount": 15}`, *bob.AccountID))
+	assert.Equal(t, models.OperationSucceeded, op.Status)
+
+	// Overdrafts are rejected before anything is queued
+	resp, err := http.Post(server.URL+"/transactions/withdraw", "application/json",
+		strings.NewReader(fmt.Sprintf(`{"account_id": %d, "amount": 1000}`, *bob.AccountID)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	assert.Equal(t, models.Money(8550), balanceOf(t, server, *alice.AccountID))
+	assert.Equal(t, models.Money(2500), balanceOf(t, server, *bob.AccountID))
+}
+
+func TestMemoryStore_IdempotentUpdate(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "carol", "", "", 0, "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.UpdateBalance(id, 500, "deposit", "key-1"))
+	assert.ErrorIs(t, store.UpdateBalance(id, 500, "deposit", "key-1"), storage.ErrDuplicateRequest)
+
+	acc, err := store.GetAccount(id)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(500), acc.Balance)
+}
+
+func TestMemoryBackend_Reversal(t *testing.T) {
+	server := newMemoryServer(t)
+
+	acc := submit(t, server, "/accounts/create", `{"name": "erin", "balance": 0}`)
+	require.Equal(t, models.OperationSucceeded, acc.Status)
+	op := submit(t, server, "/transactions/deposit", fmt.Sprintf(`{"account_id": %d, "amount": 20}`, *acc.AccountID))
+	require.Equal(t, models.OperationSucceeded, op.Status)
+
+	// Transaction 1 opened the account, transaction 2 is the deposit
+	op = submit(t, server, "/transactions/2/reverse", `{"reason": "posted to wrong account", "actor": "ops@bank"}`)
+	require.Equal(t, models.OperationSucceeded, op.Status)
+	assert.Equal(t, models.Money(0), balanceOf(t, server, *acc.AccountID))
+
+	resp, err := http.Post(server.URL+"/transactions/2/reverse", "application/json",
+		strings.NewReader(`{"reason": "again", "actor": "ops@bank"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = http.Get(fmt.Sprintf("%s/accounts/%d/transactions?sort=asc", server.URL, *acc.AccountID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var page struct {
+		Transactions []models.Transaction `json:"transactions"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Len(t, page.Transactions, 3)
+	reversal := page.Transactions[2]
+	assert.Equal(t, "reversal", reversal.Type)
+	assert.Equal(t, 2, *reversal.ReversalOf)
+	assert.Equal(t, "ops@bank", reversal.Actor)
+	assert.Equal(t, 3, *page.Transactions[1].ReversedBy)
+}
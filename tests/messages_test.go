@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessages_RoundTrip(t *testing.T) {
+	env, err := messages.New(messages.Transfer{FromAccountID: 1, ToAccountID: 2, Amount: 1050}, "")
+	require.NoError(t, err)
+	env.OperationID = 7
+	env.IdempotencyKey = "key-1"
+
+	// Without a correlation ID the message ID is used
+	assert.Equal(t, env.MessageID, env.CorrelationID)
+
+	body, err := env.Encode()
+	require.NoError(t, err)
+
+	decoded, msg, err := messages.Decode([]byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, messages.CurrentVersion, decoded.Version)
+	assert.Equal(t, env.MessageID, decoded.MessageID)
+	assert.Equal(t, 7, decoded.OperationID)
+	assert.Equal(t, "key-1", decoded.IdempotencyKey)
+	assert.Equal(t, messages.Transfer{FromAccountID: 1, ToAccountID: 2, Amount: 1050}, msg)
+}
+
+func TestMessages_DecodesVersion1(t *testing.T) {
+	env, msg, err := messages.Decode([]byte(`{"type": "withdraw", "operation_id": 3, "account_id": 5, "amount": 12.5}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, env.Version)
+	assert.Equal(t, 3, env.OperationID)
+	assert.Equal(t, messages.Withdraw{AccountID: 5, Amount: models.Money(1250)}, msg)
+}
+
+func TestMessages_RejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":       `{not json`,
+		"missing type":    `{"account_id": 1, "amount": 5}`,
+		"unknown type":    `{"type": "loan", "account_id": 1, "amount": 5}`,
+		"zero amount":     `{"version": 2, "type": "deposit", "payload": {"account_id": 1, "amount": 0}}`,
+		"missing account": `{"version": 2, "type": "withdraw", "payload": {"amount": 5}}`,
+		"same account":    `{"version": 2, "type": "transfer", "payload": {"from_account_id": 1, "to_account_id": 1, "amount": 5}}`,
+		"missing name":    `{"version": 2, "type": "account_creation", "payload": {"balance": 5}}`,
+		"future version":  `{"version": 99, "type": "deposit", "payload": {"account_id": 1, "amount": 5}}`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := messages.Decode([]byte(body))
+			assert.ErrorIs(t, err, messages.ErrInvalid)
+		})
+	}
+}
+
+func TestMessages_NewValidates(t *testing.T) {
+	_, err := messages.New(messages.Deposit{AccountID: 1, Amount: -5}, "")
+	assert.ErrorIs(t, err, messages.ErrInvalid)
+}
@@ -2,18 +2,20 @@ package mocks
 
 import (
 	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
-// MockDB simulates the behavior of storage.DB methods
+// MockDB simulates the storage repositories
 type MockDB struct {
 	mock.Mock
 }
 
 // Mock CreateAccount method
-func (m *MockDB) CreateAccount(name string, balance float64) (int, error) {
-	args := m.Called(name, balance)
+func (m *MockDB) CreateAccount(customerID *int, name, productCode, currency string, balance models.Money, idempotencyKey string) (int, error) {
+	args := m.Called(customerID, name, productCode, currency, balance, idempotencyKey)
 	return args.Int(0), args.Error(1)
 }
 
@@ -26,14 +28,388 @@ func (m *MockDB) GetAccount(id int) (*models.Account, error) {
 	return args.Get(0).(*models.Account), args.Error(1)
 }
 
+// Mock CreateCustomer method
+func (m *MockDB) CreateCustomer(c models.Customer) (*models.Customer, error) {
+	args := m.Called(c)
+	return customerResult(args)
+}
+
+// Mock GetCustomer method
+func (m *MockDB) GetCustomer(id int) (*models.Customer, error) {
+	args := m.Called(id)
+	return customerResult(args)
+}
+
+// Mock ListCustomers method
+func (m *MockDB) ListCustomers() ([]models.Customer, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Customer), args.Error(1)
+}
+
+// Mock UpdateCustomer method
+func (m *MockDB) UpdateCustomer(c models.Customer) (*models.Customer, error) {
+	args := m.Called(c)
+	return customerResult(args)
+}
+
+// Mock DeleteCustomer method
+func (m *MockDB) DeleteCustomer(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// Mock ListCustomerAccounts method
+func (m *MockDB) ListCustomerAccounts(customerID int) ([]models.Account, error) {
+	args := m.Called(customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Account), args.Error(1)
+}
+
+func customerResult(args mock.Arguments) (*models.Customer, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Customer), args.Error(1)
+}
+
 // Mock UpdateBalance method
-func (m *MockDB) UpdateBalance(accountID int, amount float64, operation string) error {
-	args := m.Called(accountID, amount, operation)
+func (m *MockDB) UpdateBalance(accountID int, amount models.Money, operation string, idempotencyKey string) error {
+	args := m.Called(accountID, amount, operation, idempotencyKey)
+	return args.Error(0)
+}
+
+// Mock Transfer method
+func (m *MockDB) Transfer(fromID, toID int, amount models.Money, idempotencyKey string) error {
+	args := m.Called(fromID, toID, amount, idempotencyKey)
+	return args.Error(0)
+}
+
+// Mock ListTransactions method
+func (m *MockDB) ListTransactions(q storage.TransactionQuery) ([]models.Transaction, error) {
+	args := m.Called(q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Transaction), args.Error(1)
+}
+
+// Mock LogTransaction method
+func (m *MockDB) LogTransaction(accountID int, amount models.Money, txType string) {
+	m.Called(accountID, amount, txType)
+}
+
+// Mock GetOperation method
+func (m *MockDB) GetOperation(id int) (*models.Operation, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Operation), args.Error(1)
+}
+
+// Mock GetOperationByIdempotencyKey method
+func (m *MockDB) GetOperationByIdempotencyKey(key string) (*models.Operation, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Operation), args.Error(1)
+}
+
+// Mock MarkOperationProcessing method
+func (m *MockDB) MarkOperationProcessing(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// Mock MarkOperationSucceeded method
+func (m *MockDB) MarkOperationSucceeded(id int, accountID int) error {
+	args := m.Called(id, accountID)
+	return args.Error(0)
+}
+
+// Mock MarkOperationFailed method
+func (m *MockDB) MarkOperationFailed(id int, reason string) error {
+	args := m.Called(id, reason)
+	return args.Error(0)
+}
+
+// Mock PlaceHold method
+func (m *MockDB) PlaceHold(accountID int, amount models.Money, reference string, expiresAt time.Time) (*models.Hold, error) {
+	args := m.Called(accountID, amount, reference, expiresAt)
+	return holdResult(args)
+}
+
+// Mock GetHold method
+func (m *MockDB) GetHold(id int) (*models.Hold, error) {
+	args := m.Called(id)
+	return holdResult(args)
+}
+
+// Mock CaptureHold method
+func (m *MockDB) CaptureHold(id int, amount models.Money) (*models.Hold, error) {
+	args := m.Called(id, amount)
+	return holdResult(args)
+}
+
+// Mock ReleaseHold method
+func (m *MockDB) ReleaseHold(id int) (*models.Hold, error) {
+	args := m.Called(id)
+	return holdResult(args)
+}
+
+func holdResult(args mock.Arguments) (*models.Hold, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+// Mock GetTransaction method
+func (m *MockDB) GetTransaction(id int) (*models.Transaction, error) {
+	args := m.Called(id)
+	return transactionResult(args)
+}
+
+// Mock ReverseTransaction method
+func (m *MockDB) ReverseTransaction(transactionID int, reason, actor, idempotencyKey string) (*models.Transaction, error) {
+	args := m.Called(transactionID, reason, actor, idempotencyKey)
+	return transactionResult(args)
+}
+
+func transactionResult(args mock.Arguments) (*models.Transaction, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// Mock CreateSchedule method
+func (m *MockDB) CreateSchedule(s models.Schedule) (*models.Schedule, error) {
+	args := m.Called(s)
+	return scheduleResult(args)
+}
+
+// Mock GetSchedule method
+func (m *MockDB) GetSchedule(id int) (*models.Schedule, error) {
+	args := m.Called(id)
+	return scheduleResult(args)
+}
+
+// Mock ListSchedules method
+func (m *MockDB) ListSchedules(accountID int) ([]models.Schedule, error) {
+	args := m.Called(accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Schedule), args.Error(1)
+}
+
+// Mock SetScheduleStatus method
+func (m *MockDB) SetScheduleStatus(id int, status string, nextRunAt *time.Time) (*models.Schedule, error) {
+	args := m.Called(id, status, nextRunAt)
+	return scheduleResult(args)
+}
+
+// Mock DueSchedules method
+func (m *MockDB) DueSchedules(now time.Time, limit int) ([]models.Schedule, error) {
+	args := m.Called(now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Schedule), args.Error(1)
+}
+
+// Mock AdvanceSchedule method
+func (m *MockDB) AdvanceSchedule(id int, from time.Time, next *time.Time) (bool, error) {
+	args := m.Called(id, from, next)
+	return args.Bool(0), args.Error(1)
+}
+
+func scheduleResult(args mock.Arguments) (*models.Schedule, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Schedule), args.Error(1)
+}
+
+// Mock CreateInterestProduct method
+func (m *MockDB) CreateInterestProduct(p models.InterestProduct) (*models.InterestProduct, error) {
+	args := m.Called(p)
+	return productResult(args)
+}
+
+// Mock GetInterestProduct method
+func (m *MockDB) GetInterestProduct(id int) (*models.InterestProduct, error) {
+	args := m.Called(id)
+	return productResult(args)
+}
+
+// Mock ListInterestProducts method
+func (m *MockDB) ListInterestProducts() ([]models.InterestProduct, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InterestProduct), args.Error(1)
+}
+
+// Mock SetInterestProduct method
+func (m *MockDB) SetInterestProduct(accountID int, productID *int, since time.Time) error {
+	args := m.Called(accountID, productID, since)
 	return args.Error(0)
 }
 
-// Mock AddTransaction method
-func (m *MockDB) AddTransaction(accountID int, amount float64, txType string) error {
-	args := m.Called(accountID, amount, txType)
+// Mock InterestAccounts method
+func (m *MockDB) InterestAccounts() ([]storage.InterestAccount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]storage.InterestAccount), args.Error(1)
+}
+
+// Mock EndOfDayBalance method
+func (m *MockDB) EndOfDayBalance(accountID int, day time.Time) (models.Money, error) {
+	args := m.Called(accountID, day)
+	return args.Get(0).(models.Money), args.Error(1)
+}
+
+// Mock AddAccrual method
+func (m *MockDB) AddAccrual(a models.InterestAccrual) error {
+	args := m.Called(a)
+	return args.Error(0)
+}
+
+// Mock ListAccruals method
+func (m *MockDB) ListAccruals(accountID int) ([]models.InterestAccrual, error) {
+	args := m.Called(accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InterestAccrual), args.Error(1)
+}
+
+// Mock PostInterest method
+func (m *MockDB) PostInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	args := m.Called(accountID, through)
+	return transactionResult(args)
+}
+
+// Mock OverdraftAccounts method
+func (m *MockDB) OverdraftAccounts() ([]storage.OverdraftAccount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]storage.OverdraftAccount), args.Error(1)
+}
+
+// Mock AddOverdraftAccrual method
+func (m *MockDB) AddOverdraftAccrual(a models.OverdraftAccrual) error {
+	args := m.Called(a)
 	return args.Error(0)
 }
+
+// Mock ListOverdraftAccruals method
+func (m *MockDB) ListOverdraftAccruals(accountID int) ([]models.OverdraftAccrual, error) {
+	args := m.Called(accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OverdraftAccrual), args.Error(1)
+}
+
+// Mock ChargeOverdraftInterest method
+func (m *MockDB) ChargeOverdraftInterest(accountID int, through time.Time) (*models.Transaction, error) {
+	args := m.Called(accountID, through)
+	return transactionResult(args)
+}
+
+func productResult(args mock.Arguments) (*models.InterestProduct, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.InterestProduct), args.Error(1)
+}
+
+// Mock SetAccountType method
+func (m *MockDB) SetAccountType(id int, accountType string) error {
+	args := m.Called(id, accountType)
+	return args.Error(0)
+}
+
+// Mock SetOverdraft method
+func (m *MockDB) SetOverdraft(id int, limit models.Money, rateBps *int, since time.Time) error {
+	args := m.Called(id, limit, rateBps, since)
+	return args.Error(0)
+}
+
+// Mock ChangeAccountStatus method
+func (m *MockDB) ChangeAccountStatus(id int, action, reason, actor string) error {
+	args := m.Called(id, action, reason, actor)
+	return args.Error(0)
+}
+
+// Mock CloseAccount method
+func (m *MockDB) CloseAccount(id int, reason, actor string, payout bool) error {
+	args := m.Called(id, reason, actor, payout)
+	return args.Error(0)
+}
+
+// Mock ListAccountEvents method
+func (m *MockDB) ListAccountEvents(id int) ([]models.AccountEvent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AccountEvent), args.Error(1)
+}
+
+// Mock MarkDormant method
+func (m *MockDB) MarkDormant(inactiveSince time.Time) (int, error) {
+	args := m.Called(inactiveSince)
+	return args.Int(0), args.Error(1)
+}
+
+// Mock CreateFeeRule method
+func (m *MockDB) CreateFeeRule(r models.FeeRule) (*models.FeeRule, error) {
+	args := m.Called(r)
+	return feeRuleResult(args)
+}
+
+// Mock ListFeeRules method
+func (m *MockDB) ListFeeRules() ([]models.FeeRule, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.FeeRule), args.Error(1)
+}
+
+// Mock DeactivateFeeRule method
+func (m *MockDB) DeactivateFeeRule(id int) (*models.FeeRule, error) {
+	args := m.Called(id)
+	return feeRuleResult(args)
+}
+
+// Mock PreviewFees method
+func (m *MockDB) PreviewFees(accountID int, txType string, amount models.Money) ([]models.FeeCharge, error) {
+	args := m.Called(accountID, txType, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.FeeCharge), args.Error(1)
+}
+
+func feeRuleResult(args mock.Arguments) (*models.FeeRule, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FeeRule), args.Error(1)
+}
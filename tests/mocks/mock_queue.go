@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,8 +12,46 @@ type MockQueue struct {
 	mock.Mock
 }
 
-// Mock PublishMessage method
-func (m *MockQueue) PublishMessage(message string) error {
-	args := m.Called(message)
+// Mock Publish method
+func (m *MockQueue) Publish(opType string, idempotencyKey string, env *messages.Envelope) (*models.Operation, bool, error) {
+	args := m.Called(opType, idempotencyKey, env)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.Operation), args.Bool(1), args.Error(2)
+}
+
+// MockDelivery simulates a message received by the worker
+type MockDelivery struct {
+	mock.Mock
+	Payload []byte
+	Retries int
+}
+
+// Body returns the message payload
+func (m *MockDelivery) Body() []byte {
+	return m.Payload
+}
+
+// RetryCount returns how often the message was retried
+func (m *MockDelivery) RetryCount() int {
+	return m.Retries
+}
+
+// Mock Ack method
+func (m *MockDelivery) Ack() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// Mock Retry method
+func (m *MockDelivery) Retry(reason string) error {
+	args := m.Called(reason)
+	return args.Error(0)
+}
+
+// Mock DeadLetter method
+func (m *MockDelivery) DeadLetter(reason string) error {
+	args := m.Called(reason)
 	return args.Error(0)
 }
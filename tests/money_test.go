@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney_Valid(t *testing.T) {
+	cases := map[string]models.Money{
+		"0":       0,
+		"12":      1200,
+		"0.1":     10,
+		"0.01":    1,
+		"1049.99": 104999,
+		"-5.5":    -550,
+	}
+	for input, want := range cases {
+		got, err := models.ParseMoney(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+}
+
+func TestParseMoney_RejectsInvalid(t *testing.T) {
+	for _, input := range []string{"", "-", "1.", ".5", "0.001", "1e3", "1,000.00", "12a", "99999999999999999999"} {
+		_, err := models.ParseMoney(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMoney_JSONRoundTrip(t *testing.T) {
+	var tx models.Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{"account_id": 1, "amount": 0.30}`), &tx))
+	assert.Equal(t, models.Money(30), tx.Amount)
+
+	// Sums stay exact where float64 would drift
+	assert.Equal(t, "0.30", (models.Money(10) + models.Money(20)).String())
+
+	out, err := json.Marshal(models.Account{ID: 1, Name: "John Doe", Balance: -1205, AvailableBalance: -1705})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 1, "name": "John Doe", "balance": -12.05, "available_balance": -17.05}`, string(out))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "7.25"}`), &tx))
+	assert.Equal(t, models.Money(725), tx.Amount)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 1.005}`), &tx))
+}
+
+func TestMoney_FormatInCurrency(t *testing.T) {
+	assert.Equal(t, "1500", models.Money(150000).Format("JPY"))
+	assert.Equal(t, "-1500", models.Money(-150000).Format("KRW"))
+	assert.Equal(t, "1500.00", models.Money(150000).Format("USD"))
+	assert.Equal(t, "0.05", models.Money(5).Format("EUR"))
+	assert.Equal(t, "1500.00", models.Money(150000).Format(""))
+
+	// An amount finer than its currency keeps its cents rather than losing them
+	assert.Equal(t, "1500.50", models.Money(150050).Format("JPY"))
+
+	balanceAfter := models.Money(250000)
+	out, err := json.Marshal(models.Transaction{ID: 2, AccountID: 1, Amount: 100000, Currency: "JPY", Type: "deposit", BalanceAfter: &balanceAfter})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 2, "account_id": 1, "amount": 1000, "currency": "JPY", "type": "deposit", "balance_after": 2500, "created_at": "0001-01-01T00:00:00Z"}`, string(out))
+
+	out, err = json.Marshal(models.Account{ID: 1, Name: "aiko", Currency: "JPY", Balance: 150000, AvailableBalance: 140000, OverdraftLimit: 5000})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 1, "name": "aiko", "currency": "JPY", "balance": 1500, "available_balance": 1400, "overdraft_limit": 50}`, string(out))
+	assert.NotContains(t, string(out), ".00")
+
+	var acc models.Account
+	require.NoError(t, json.Unmarshal(out, &acc))
+	assert.Equal(t, models.Money(150000), acc.Balance)
+	assert.Equal(t, models.Money(5000), acc.OverdraftLimit)
+}
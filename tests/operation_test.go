@@ -0,0 +1,20 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOperation_InvalidID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/operations/abc", nil)
+	req.SetPathValue("id", "abc")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).GetOperation(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid operation ID")
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/storage"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayOutbox_PublishesOutsideTransaction(t *testing.T) {
+	connectTestDB(t)
+
+	ctx := context.Background()
+	var first, second int64
+	payload := fmt.Sprintf("outbox-%d", time.Now().UnixNano())
+	require.NoError(t, storage.DB.QueryRow(ctx, "INSERT INTO outbox (payload) VALUES ($1) RETURNING id", payload+"-1").Scan(&first))
+	require.NoError(t, storage.DB.QueryRow(ctx, "INSERT INTO outbox (payload) VALUES ($1) RETURNING id", payload+"-2").Scan(&second))
+
+	var published []string
+	_, err := storage.RelayOutbox(1000, time.Minute, func(p string) error {
+		published = append(published, p)
+		if p != payload+"-1" {
+			return nil
+		}
+
+		// No row lock is held while publishing, and a second relay skips
+		// the claimed batch
+		_, err := storage.DB.Exec(ctx, "SELECT id FROM outbox WHERE id = $1 FOR UPDATE NOWAIT", second)
+		assert.NoError(t, err)
+		_, err = storage.RelayOutbox(1000, time.Minute, func(p string) error {
+			assert.NotContains(t, []string{payload + "-1", payload + "-2"}, p)
+			return nil
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Subset(t, published, []string{payload + "-1", payload + "-2"})
+
+	var unsent int
+	require.NoError(t, storage.DB.QueryRow(ctx, "SELECT COUNT(*) FROM outbox WHERE id IN ($1, $2) AND sent_at IS NULL", first, second).Scan(&unsent))
+	assert.Equal(t, 0, unsent)
+}
+
+func TestRelayOutbox_FailedPublishReleasesBatch(t *testing.T) {
+	connectTestDB(t)
+
+	ctx := context.Background()
+	var first, second int64
+	payload := fmt.Sprintf("outbox-fail-%d", time.Now().UnixNano())
+	require.NoError(t, storage.DB.QueryRow(ctx, "INSERT INTO outbox (payload) VALUES ($1) RETURNING id", payload+"-1").Scan(&first))
+	require.NoError(t, storage.DB.QueryRow(ctx, "INSERT INTO outbox (payload) VALUES ($1) RETURNING id", payload+"-2").Scan(&second))
+
+	_, err := storage.RelayOutbox(1000, time.Minute, func(p string) error {
+		if p == payload+"-1" {
+			return fmt.Errorf("broker unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Both messages can be claimed again straight away, in order
+	var claimed int
+	require.NoError(t, storage.DB.QueryRow(ctx,
+		"SELECT COUNT(*) FROM outbox WHERE id IN ($1, $2) AND sent_at IS NULL AND locked_until IS NULL", first, second).Scan(&claimed))
+	assert.Equal(t, 2, claimed)
+
+	var lastError string
+	require.NoError(t, storage.DB.QueryRow(ctx, "SELECT last_error FROM outbox WHERE id = $1", first).Scan(&lastError))
+	assert.Equal(t, "broker unavailable", lastError)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/interest"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverdraftAccrual(t *testing.T) {
+	// 18.25% of 1000.00 over 365 days is 50 cents a day
+	assert.Equal(t, int64(50*models.AccrualUnitsPerCent), interest.OverdraftAccrual(1825, -100000))
+	assert.Equal(t, int64(0), interest.OverdraftAccrual(1825, 0))
+	assert.Equal(t, int64(0), interest.OverdraftAccrual(1825, 100000))
+}
+
+func TestMemoryStore_OverdraftLimit(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "hana", "", "", 2000, "")
+	require.NoError(t, err)
+	other, err := store.CreateAccount(nil, "ivan", "", "", 0, "")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, store.UpdateBalance(id, 3000, "withdraw", ""), storage.ErrInsufficientFunds)
+
+	require.NoError(t, store.SetOverdraft(id, 5000, nil, utcTime("2026-01-01T00:00:00Z")))
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(7000), acc.AvailableBalance)
+
+	// Debits may take the balance down to the limit and no further
+	require.NoError(t, store.UpdateBalance(id, 3000, "withdraw", ""))
+	require.NoError(t, store.Transfer(id, other, 4000, ""))
+	assert.ErrorIs(t, store.Transfer(id, other, 1, ""), storage.ErrInsufficientFunds)
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(-5000), acc.Balance)
+	assert.Equal(t, models.Money(0), acc.AvailableBalance)
+
+	// Revoking the limit leaves the balance overdrawn but blocks new debits
+	require.NoError(t, store.SetOverdraft(id, 0, nil, utcTime("2026-01-01T00:00:00Z")))
+	require.NoError(t, store.UpdateBalance(id, 1000, "deposit", ""))
+	acc, _ = store.GetAccount(id)
+	assert.Equal(t, models.Money(-4000), acc.Balance)
+	assert.Equal(t, models.Money(-4000), acc.AvailableBalance)
+	_, err = store.PlaceHold(id, 1, "", utcTime("2099-01-01T00:00:00Z"))
+	assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+}
+
+func TestInterestEngine_ChargesOverdraftMonthly(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "jude", "", "", 0, "")
+	require.NoError(t, err)
+	rate := 1825
+	require.NoError(t, store.SetOverdraft(id, 100000, &rate, utcTime("2099-01-15T00:00:00Z")))
+	require.NoError(t, store.UpdateBalance(id, 100000, "withdraw", ""))
+
+	engine := &interest.Engine{Interest: store}
+	require.NoError(t, engine.RunThrough(utcTime("2099-02-10T08:00:00Z")))
+
+	// January 15th to February 9th are accrued; only January's 17 days of
+	// 50 cents are charged
+	accruals, err := store.ListOverdraftAccruals(id)
+	require.NoError(t, err)
+	require.Len(t, accruals, 26)
+	assert.Nil(t, accruals[0].TransactionID)
+	assert.NotNil(t, accruals[9].TransactionID)
+
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(-100850), acc.Balance)
+	assert.Equal(t, models.Money(-850), acc.AvailableBalance)
+
+	// Running again charges nothing twice
+	require.NoError(t, engine.RunThrough(utcTime("2099-02-10T20:00:00Z")))
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "overdraft_interest", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.Money(850), history[0].Amount)
+}
+
+func TestSetOverdraft(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	rate := 1990
+	mockDB.On("SetOverdraft", 1, models.Money(50000), &rate, mock.Anything).Return(nil)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, OverdraftLimit: 50000, OverdraftRateBps: rate}, nil)
+
+	req := httptest.NewRequest("PUT", "/accounts/1/overdraft", bytes.NewBufferString(`{"limit": 500.00, "rate_bps": 1990}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).SetOverdraft(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"overdraft_limit":500.00`)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSetOverdraft_Negative(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/accounts/1/overdraft", bytes.NewBufferString(`{"limit": -5}`))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).SetOverdraft(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRevokeOverdraft_KeepsRate(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("SetOverdraft", 1, models.Money(0), (*int)(nil), mock.Anything).Return(nil)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Balance: -2000, OverdraftRateBps: 1990}, nil)
+
+	req := httptest.NewRequest("DELETE", "/accounts/1/overdraft", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).RevokeOverdraft(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockDB.AssertExpectations(t)
+}
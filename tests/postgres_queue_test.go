@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresQueue_DeliversRetriesAndAcks(t *testing.T) {
+	connectTestDB(t)
+	_, err := storage.DB.Exec(context.Background(), "DELETE FROM jobs")
+	require.NoError(t, err)
+
+	q := queue.NewPostgresQueue(storage.DB)
+	q.PollInterval = 100 * time.Millisecond
+	deliveries, err := q.ConsumeMessages(10)
+	require.NoError(t, err)
+
+	require.NoError(t, q.PublishMessage(`{"type": "deposit"}`))
+
+	var msg queue.Delivery
+	select {
+	case msg = <-deliveries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not delivered")
+	}
+	assert.JSONEq(t, `{"type": "deposit"}`, string(msg.Body()))
+	assert.Equal(t, 0, msg.RetryCount())
+
+	// The first retry is delayed by the one second base backoff
+	require.NoError(t, msg.Retry("database unavailable"))
+	select {
+	case msg = <-deliveries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retried job was not delivered")
+	}
+	assert.Equal(t, 1, msg.RetryCount())
+
+	require.NoError(t, msg.Ack())
+
+	var remaining int
+	require.NoError(t, storage.DB.QueryRow(context.Background(), "SELECT COUNT(*) FROM jobs").Scan(&remaining))
+	assert.Equal(t, 0, remaining)
+}
+
+func TestPostgresQueue_DeadLetterKeepsJob(t *testing.T) {
+	connectTestDB(t)
+	_, err := storage.DB.Exec(context.Background(), "DELETE FROM jobs")
+	require.NoError(t, err)
+
+	q := queue.NewPostgresQueue(storage.DB)
+	deliveries, err := q.ConsumeMessages(10)
+	require.NoError(t, err)
+	require.NoError(t, q.PublishMessage(`not json`))
+
+	msg := <-deliveries
+	require.NoError(t, msg.DeadLetter("invalid message"))
+
+	var reason string
+	err = storage.DB.QueryRow(context.Background(), "SELECT last_error FROM jobs WHERE dead_at IS NOT NULL").Scan(&reason)
+	require.NoError(t, err)
+	assert.Equal(t, "invalid message", reason)
+}
+
+func TestPostgresQueue_DeliversBatchInQueueOrder(t *testing.T) {
+	connectTestDB(t)
+	_, err := storage.DB.Exec(context.Background(), "DELETE FROM jobs")
+	require.NoError(t, err)
+
+	// Queue the jobs first so they are claimed together in one batch
+	q := queue.NewPostgresQueue(storage.DB)
+	for i := 1; i <= 20; i++ {
+		require.NoError(t, q.PublishMessage(fmt.Sprintf(`{"n": %d}`, i)))
+	}
+	deliveries, err := q.ConsumeMessages(20)
+	require.NoError(t, err)
+
+	for i := 1; i <= 20; i++ {
+		select {
+		case msg := <-deliveries:
+			assert.JSONEq(t, fmt.Sprintf(`{"n": %d}`, i), string(msg.Body()))
+			require.NoError(t, msg.Ack())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job %d was not delivered", i)
+		}
+	}
+}
+
+func TestPostgresQueue_HeldJobIsNotClaimedAgain(t *testing.T) {
+	connectTestDB(t)
+	_, err := storage.DB.Exec(context.Background(), "DELETE FROM jobs")
+	require.NoError(t, err)
+
+	q := queue.NewPostgresQueue(storage.DB)
+	q.VisibilityTimeout = 300 * time.Millisecond
+	q.PollInterval = 50 * time.Millisecond
+	deliveries, err := q.ConsumeMessages(1)
+	require.NoError(t, err)
+	require.NoError(t, q.PublishMessage(`{"n": 1}`))
+	require.NoError(t, q.PublishMessage(`{"n": 2}`))
+
+	msg := <-deliveries
+	assert.JSONEq(t, `{"n": 1}`, string(msg.Body()))
+
+	// Held well past the visibility timeout, the job is neither delivered
+	// again nor overtaken while the prefetch of one is used up
+	select {
+	case again := <-deliveries:
+		t.Fatalf("unexpected delivery while the first job was held: %s", again.Body())
+	case <-time.After(3 * q.VisibilityTimeout):
+	}
+	require.NoError(t, msg.Ack())
+
+	select {
+	case msg = <-deliveries:
+		assert.JSONEq(t, `{"n": 2}`, string(msg.Body()))
+		require.NoError(t, msg.Ack())
+	case <-time.After(5 * time.Second):
+		t.Fatal("second job was not delivered")
+	}
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductsCheck(t *testing.T) {
+	savings, _ := products.Get(products.Savings)
+	business, _ := products.Get(products.Business)
+	escrow, _ := products.Get(products.Escrow)
+
+	for _, tc := range []struct {
+		name        string
+		product     models.Product
+		txType      string
+		amount      models.Money
+		available   models.Money
+		withdrawals int
+		allowed     bool
+	}{
+		{"savings keeps its minimum", savings, "withdraw", 5000, 15000, 0, true},
+		{"savings below its minimum", savings, "withdraw", 5001, 15000, 0, false},
+		{"savings sixth withdrawal", savings, "transfer_out", 100, 50000, 5, true},
+		{"savings seventh withdrawal", savings, "transfer_out", 100, 50000, 6, false},
+		{"savings deposit past the limit", savings, "deposit", 100, 0, 6, true},
+		{"savings takes no holds", savings, "capture", 100, 50000, 0, false},
+		{"business withdrawal at the cap", business, "withdraw", 5000000, 9000000, 0, true},
+		{"business withdrawal over the cap", business, "withdraw", 5000001, 9000000, 0, false},
+		{"business hold over the withdrawal cap", business, "capture", 6000000, 9000000, 0, true},
+		{"escrow withdrawal", escrow, "withdraw", 100, 50000, 0, false},
+		{"escrow release by transfer", escrow, "transfer_out", 50000, 50000, 0, true},
+	} {
+		err := products.Check(tc.product, tc.txType, tc.amount, tc.available, tc.withdrawals)
+		assert.Equal(t, tc.allowed, err == nil, "%s: %v", tc.name, err)
+	}
+}
+
+func TestMemoryStore_ProductRules(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+
+	_, err := store.CreateAccount(nil, "xena", products.Savings, "", 9999, "")
+	assert.ErrorIs(t, err, storage.ErrProductRule)
+	_, err = store.CreateAccount(nil, "xena", "platinum", "", 0, "")
+	assert.ErrorIs(t, err, storage.ErrProductRule)
+
+	savings, err := store.CreateAccount(nil, "xena", products.Savings, "", 100000, "")
+	require.NoError(t, err)
+	escrow, err := store.CreateAccount(nil, "yuri", products.Escrow, "", 0, "")
+	require.NoError(t, err)
+	checking, err := store.CreateAccount(nil, "zoe", "", "", 0, "")
+	require.NoError(t, err)
+	acc, _ := store.GetAccount(checking)
+	assert.Equal(t, products.Checking, acc.ProductCode)
+
+	// Savings keeps its minimum balance and allows six withdrawals a month
+	assert.ErrorIs(t, store.UpdateBalance(savings, 90001, "withdraw", ""), storage.ErrProductRule)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.UpdateBalance(savings, 100, "withdraw", ""))
+		require.NoError(t, store.Transfer(savings, escrow, 100, ""))
+	}
+	assert.ErrorIs(t, store.UpdateBalance(savings, 100, "withdraw", ""), storage.ErrProductRule)
+	require.NoError(t, store.UpdateBalance(savings, 100, "deposit", ""))
+	_, err = store.PlaceHold(savings, 100, "", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrProductRule)
+
+	// Escrow releases funds by transfer only
+	assert.ErrorIs(t, store.UpdateBalance(escrow, 100, "withdraw", ""), storage.ErrProductRule)
+	require.NoError(t, store.Transfer(escrow, checking, 300, ""))
+}
+
+func TestMemoryStore_ProductMinimumIgnoresOverdraft(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	savings, err := store.CreateAccount(nil, "xena", products.Savings, "", 10000, "")
+	require.NoError(t, err)
+	require.NoError(t, store.SetOverdraft(savings, 50000, nil, time.Now()))
+
+	// The overdraft limit does not lower the savings minimum balance
+	assert.ErrorIs(t, store.UpdateBalance(savings, 50000, "withdraw", ""), storage.ErrProductRule)
+	assert.ErrorIs(t, store.UpdateBalance(savings, 1, "withdraw", ""), storage.ErrProductRule)
+	require.NoError(t, store.UpdateBalance(savings, 500, "deposit", ""))
+	require.NoError(t, store.UpdateBalance(savings, 500, "withdraw", ""))
+
+	acc, _ := store.GetAccount(savings)
+	assert.Equal(t, models.Money(10000), acc.Balance)
+}
+
+func TestWithdraw_SavingsMinimumWithOverdraft(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{
+		ID: 1, Status: models.AccountActive, ProductCode: products.Savings, Currency: "USD",
+		Balance: 10000, AvailableBalance: 60000, OverdraftLimit: 50000,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/withdraw", bytes.NewBufferString(`{"account_id": 1, "amount": 500}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "savings accounts must keep a balance")
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWithdraw_EscrowAccount(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Status: models.AccountActive, ProductCode: products.Escrow, Balance: 10000, AvailableBalance: 10000}, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/withdraw", bytes.NewBufferString(`{"account_id": 1, "amount": 10}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "escrow accounts do not allow withdraw")
+	mockQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateAccount_ProductValidation(t *testing.T) {
+	for _, body := range []string{
+		`{"name": "amy", "product_code": "platinum", "balance": 10}`,
+		`{"name": "amy", "product_code": "savings", "balance": 10}`,
+	} {
+		req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+
+		newTestHandler(nil, nil).CreateAccount(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
+
+func TestCreateAccount_PublishesProductCode(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+	mockQueue.On("Publish", "account_creation", "", mock.Anything).Return(&models.Operation{ID: 1, Status: models.OperationQueued}, true, nil)
+
+	req := httptest.NewRequest("POST", "/accounts/create", bytes.NewBufferString(`{"name": "amy", "product_code": "savings", "balance": 250}`))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, mockQueue).CreateAccount(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	env := mockQueue.Calls[0].Arguments.Get(2).(*messages.Envelope)
+	var msg messages.AccountCreation
+	require.NoError(t, json.Unmarshal(env.Payload, &msg))
+	assert.Equal(t, products.Savings, msg.ProductCode)
+}
+
+func TestGetProduct(t *testing.T) {
+	req := httptest.NewRequest("GET", "/products/savings", nil)
+	req.SetPathValue("code", "savings")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).GetProduct(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var product models.Product
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&product))
+	assert.Equal(t, models.Money(10000), product.MinBalance)
+	assert.Equal(t, "USD", product.Currency)
+
+	req = httptest.NewRequest("GET", "/products/platinum", nil)
+	req.SetPathValue("code", "platinum")
+	rec = httptest.NewRecorder()
+
+	newTestHandler(nil, nil).GetProduct(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
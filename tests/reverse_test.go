@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func reverseRequest(id, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/transactions/"+id+"/reverse", bytes.NewBufferString(body))
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestReverseTransaction_Success(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockQueue := new(mocks.MockQueue)
+
+	mockDB.On("GetTransaction", 5).Return(&models.Transaction{ID: 5, AccountID: 1, Amount: 2000, Type: "deposit"}, nil)
+	mockQueue.On("Publish", "reversal", "", mock.Anything).Return(&models.Operation{ID: 1, Type: "reversal", Status: models.OperationQueued}, true, nil)
+
+	rec := httptest.NewRecorder()
+	newTestHandler(mockDB, mockQueue).ReverseTransaction(rec, reverseRequest("5", `{"reason": "duplicate", "actor": "ops"}`))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Reversal request sent to queue")
+	mockQueue.AssertExpectations(t)
+}
+
+func TestReverseTransaction_RequiresReasonAndActor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler(nil, nil).ReverseTransaction(rec, reverseRequest("5", `{"reason": "duplicate"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Reason and actor are required")
+}
+
+func TestReverseTransaction_NotReversible(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetTransaction", 5).Return(&models.Transaction{ID: 5, AccountID: 1, Amount: 2000, Type: "transfer_out"}, nil)
+
+	rec := httptest.NewRecorder()
+	newTestHandler(mockDB, nil).ReverseTransaction(rec, reverseRequest("5", `{"reason": "duplicate", "actor": "ops"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestReverseTransaction_AlreadyReversed(t *testing.T) {
+	reversedBy := 6
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetTransaction", 5).Return(&models.Transaction{ID: 5, AccountID: 1, Amount: 2000, Type: "deposit", ReversedBy: &reversedBy}, nil)
+
+	rec := httptest.NewRecorder()
+	newTestHandler(mockDB, nil).ReverseTransaction(rec, reverseRequest("5", `{"reason": "duplicate", "actor": "ops"}`))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestReverseTransaction_NotFound(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetTransaction", 5).Return(nil, storage.ErrNotFound)
+
+	rec := httptest.NewRecorder()
+	newTestHandler(mockDB, nil).ReverseTransaction(rec, reverseRequest("5", `{"reason": "duplicate", "actor": "ops"}`))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMemoryStore_ReversalRefundsFees(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "rhea", "", "", 10000, "")
+	require.NoError(t, err)
+	_, err = store.CreateFeeRule(models.FeeRule{Name: "atm", TransactionType: "withdraw", FlatFee: 150})
+	require.NoError(t, err)
+
+	require.NoError(t, store.UpdateBalance(id, 1000, "withdraw", ""))
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	withdrawal, fee := history[1], history[2]
+	require.Equal(t, "fee", fee.Type)
+
+	// Undoing the withdrawal refunds its fee too
+	reversal, err := store.ReverseTransaction(withdrawal.ID, "teller error", "ops", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1000), reversal.Amount)
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(10000), acc.Balance)
+
+	history, err = store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "reversal", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.NotNil(t, history[0].ReversalOf)
+	assert.Equal(t, fee.ID, *history[0].ReversalOf)
+	assert.Equal(t, models.Money(150), history[0].Amount)
+	assert.Equal(t, withdrawal.ID, *history[1].ReversalOf)
+
+	// The refunded fee cannot be refunded again
+	_, err = store.ReverseTransaction(withdrawal.ID, "teller error", "ops", "")
+	assert.ErrorIs(t, err, storage.ErrAlreadyReversed)
+}
+
+func TestMemoryStore_DepositReversalCountsRefundedFees(t *testing.T) {
+	store := storage.NewMemory(func(string) error { return nil })
+	id, err := store.CreateAccount(nil, "sven", "", "", 0, "")
+	require.NoError(t, err)
+	_, err = store.CreateFeeRule(models.FeeRule{Name: "cash deposit", TransactionType: "deposit", FlatFee: 200})
+	require.NoError(t, err)
+
+	// The deposit leaves 8.00 after its fee, and the refund covers the rest
+	require.NoError(t, store.UpdateBalance(id, 1000, "deposit", ""))
+	history, err := store.ListTransactions(storage.TransactionQuery{AccountID: id, Type: "deposit", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	_, err = store.ReverseTransaction(history[0].ID, "wrong account", "ops", "")
+	require.NoError(t, err)
+	acc, _ := store.GetAccount(id)
+	assert.Equal(t, models.Money(0), acc.Balance)
+}
@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/messages"
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/schedule"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/tests/mocks"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func utcTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		expr, after, want string
+	}{
+		{"*/15 * * * *", "2026-03-01T10:07:30Z", "2026-03-01T10:15:00Z"},
+		{"0 9 1 * *", "2026-01-15T00:00:00Z", "2026-02-01T09:00:00Z"},
+		{"0 9 1 * *", "2026-02-01T09:00:00Z", "2026-03-01T09:00:00Z"},
+		{"30 17 * * 5", "2026-10-18T12:00:00Z", "2026-10-23T17:30:00Z"},
+		{"0 0 * * 7", "2026-10-18T00:00:00Z", "2026-10-25T00:00:00Z"},
+		{"0 12 * * 1-5", "2026-10-17T13:00:00Z", "2026-10-19T12:00:00Z"},
+		{"0 0 31 * *", "2026-04-01T00:00:00Z", "2026-05-31T00:00:00Z"},
+		{"0 0 29 2 *", "2026-01-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		// Both day fields restricted match either of them
+		{"0 0 13 * 5", "2026-10-10T00:00:00Z", "2026-10-13T00:00:00Z"},
+		{"0 0 13 * 5", "2026-10-13T00:00:00Z", "2026-10-16T00:00:00Z"},
+		{"@daily", "2026-10-18T05:00:00Z", "2026-10-19T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		rule, err := schedule.ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, utcTime(tt.want), rule.Next(utcTime(tt.after)), "%s after %s", tt.expr, tt.after)
+	}
+}
+
+func TestCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := schedule.ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+
+	// February 30th never comes
+	rule, err := schedule.ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, rule.Next(time.Now()).IsZero())
+}
+
+func TestParseRule_Interval(t *testing.T) {
+	start := utcTime("2026-01-01T00:00:00Z")
+	rule, err := schedule.ParseRule("", 90*time.Minute, start)
+	require.NoError(t, err)
+	assert.Equal(t, start, schedule.First(rule, start))
+	assert.Equal(t, utcTime("2026-01-01T01:30:00Z"), rule.Next(start))
+	assert.Equal(t, utcTime("2026-01-01T04:30:00Z"), rule.Next(utcTime("2026-01-01T03:10:00Z")))
+
+	_, err = schedule.ParseRule("", 30*time.Second, start)
+	assert.Error(t, err)
+	_, err = schedule.ParseRule("@daily", time.Hour, start)
+	assert.Error(t, err)
+	_, err = schedule.ParseRule("", 0, start)
+	assert.Error(t, err)
+}
+
+// newScheduledStore returns a memory store whose published messages are
+// collected instead of processed
+func newScheduledStore() (*storage.Memory, func() []*messages.Envelope) {
+	var mu sync.Mutex
+	var published []*messages.Envelope
+	store := storage.NewMemory(func(body string) error {
+		env, _, err := messages.Decode([]byte(body))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, env)
+		return nil
+	})
+	return store, func() []*messages.Envelope {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*messages.Envelope(nil), published...)
+	}
+}
+
+func TestScheduler_QueuesEachOccurrenceOnce(t *testing.T) {
+	store, published := newScheduledStore()
+	start := utcTime("2026-01-01T00:00:00Z")
+	end := utcTime("2026-01-01T05:00:00Z")
+	sched, err := store.CreateSchedule(models.Schedule{
+		Type: "deposit", AccountID: 1, Amount: 10000, IntervalSeconds: 3600,
+		StartAt: start, EndAt: &end, NextRunAt: &start, Status: models.ScheduleActive,
+	})
+	require.NoError(t, err)
+
+	scheduler := &schedule.Scheduler{Schedules: store, Publisher: store, BatchSize: 10}
+
+	// Missed occurrences are caught up, and running again queues nothing new
+	queued, err := scheduler.RunDue(utcTime("2026-01-01T02:30:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, queued)
+	queued, err = scheduler.RunDue(utcTime("2026-01-01T02:30:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, queued)
+
+	sched, _ = store.GetSchedule(sched.ID)
+	assert.Equal(t, utcTime("2026-01-01T03:00:00Z"), *sched.NextRunAt)
+
+	envs := published()
+	require.Len(t, envs, 3)
+	assert.Equal(t, messages.TypeDeposit, envs[0].Type)
+	assert.Equal(t, "schedule:1:2026-01-01T00:00:00Z", envs[0].IdempotencyKey)
+	assert.Equal(t, "schedule:1:2026-01-01T02:00:00Z", envs[2].IdempotencyKey)
+	assert.Equal(t, "schedule:1", envs[0].CorrelationID)
+
+	// The schedule completes after its last occurrence before end_at
+	queued, err = scheduler.RunDue(utcTime("2026-01-02T00:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, queued)
+	sched, _ = store.GetSchedule(sched.ID)
+	assert.Equal(t, models.ScheduleCompleted, sched.Status)
+	assert.Nil(t, sched.NextRunAt)
+}
+
+func TestScheduler_ConcurrentSchedulersPublishOnce(t *testing.T) {
+	store, published := newScheduledStore()
+	start := utcTime("2026-01-01T00:00:00Z")
+	_, err := store.CreateSchedule(models.Schedule{
+		Type: "withdraw", AccountID: 1, Amount: 500, Cron: "0 * * * *",
+		StartAt: start, NextRunAt: &start, Status: models.ScheduleActive,
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler := &schedule.Scheduler{Schedules: store, Publisher: store, BatchSize: 10}
+			scheduler.RunDue(utcTime("2026-01-01T09:59:00Z"))
+		}()
+	}
+	wg.Wait()
+
+	// Ten hourly occurrences, each published by exactly one scheduler
+	keys := map[string]bool{}
+	for _, env := range published() {
+		assert.False(t, keys[env.IdempotencyKey], env.IdempotencyKey)
+		keys[env.IdempotencyKey] = true
+	}
+	assert.Len(t, keys, 10)
+}
+
+func TestScheduler_SkipsPausedSchedules(t *testing.T) {
+	store, published := newScheduledStore()
+	start := utcTime("2026-01-01T00:00:00Z")
+	sched, err := store.CreateSchedule(models.Schedule{
+		Type: "deposit", AccountID: 1, Amount: 500, Cron: "@daily",
+		StartAt: start, NextRunAt: &start, Status: models.ScheduleActive,
+	})
+	require.NoError(t, err)
+	_, err = store.SetScheduleStatus(sched.ID, models.SchedulePaused, nil)
+	require.NoError(t, err)
+
+	scheduler := &schedule.Scheduler{Schedules: store, Publisher: store, BatchSize: 10}
+	queued, err := scheduler.RunDue(utcTime("2026-01-05T00:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, queued)
+	assert.Empty(t, published())
+
+	_, err = store.SetScheduleStatus(sched.ID, models.ScheduleCancelled, nil)
+	require.NoError(t, err)
+	_, err = store.SetScheduleStatus(sched.ID, models.ScheduleActive, nil)
+	assert.ErrorIs(t, err, storage.ErrScheduleClosed)
+}
+
+func TestCreateSchedule_Success(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("GetAccount", 1).Return(&models.Account{ID: 1, Currency: "USD"}, nil)
+	mockDB.On("GetAccount", 2).Return(&models.Account{ID: 2, Currency: "USD"}, nil)
+	mockDB.On("CreateSchedule", mock.MatchedBy(func(s models.Schedule) bool {
+		return s.Type == "transfer" && *s.ToAccountID == 2 && s.Amount == 2500 &&
+			s.NextRunAt.Equal(utcTime("2099-01-01T09:00:00Z")) && s.Status == models.ScheduleActive
+	})).Return(&models.Schedule{ID: 7, Status: models.ScheduleActive}, nil)
+
+	body := `{"type": "transfer", "account_id": 1, "to_account_id": 2, "amount": 25, "cron": "0 9 1 * *", "start_at": "2099-01-01T00:00:00Z"}`
+	req := httptest.NewRequest("POST", "/schedules", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CreateSchedule(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var sched models.Schedule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, 7, sched.ID)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCreateSchedule_Invalid(t *testing.T) {
+	for _, body := range []string{
+		`{"type": "fee", "account_id": 1, "amount": 25, "cron": "@daily"}`,
+		`{"type": "deposit", "account_id": 1, "amount": 0, "cron": "@daily"}`,
+		`{"type": "deposit", "account_id": 1, "amount": 25}`,
+		`{"type": "deposit", "account_id": 1, "amount": 25, "cron": "@daily", "interval_seconds": 3600}`,
+		`{"type": "deposit", "account_id": 1, "amount": 25, "cron": "61 * * * *"}`,
+		`{"type": "deposit", "account_id": 1, "amount": 25, "interval_seconds": 5}`,
+		`{"type": "transfer", "account_id": 1, "amount": 25, "cron": "@daily"}`,
+		`{"type": "deposit", "account_id": 1, "amount": 25, "cron": "@daily", "start_at": "2099-01-01T00:00:00Z", "end_at": "2098-01-01T00:00:00Z"}`,
+	} {
+		req := httptest.NewRequest("POST", "/schedules", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+
+		newTestHandler(nil, nil).CreateSchedule(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
+
+func TestResumeSchedule_SkipsMissedOccurrences(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	start := utcTime("2026-01-01T00:00:00Z")
+	mockDB.On("GetSchedule", 3).Return(&models.Schedule{ID: 3, Cron: "0 * * * *", StartAt: start, NextRunAt: &start, Status: models.SchedulePaused}, nil)
+	mockDB.On("SetScheduleStatus", 3, models.ScheduleActive, mock.MatchedBy(func(next *time.Time) bool {
+		return next.After(time.Now()) && next.Before(time.Now().Add(time.Hour))
+	})).Return(&models.Schedule{ID: 3, Status: models.ScheduleActive}, nil)
+
+	req := httptest.NewRequest("POST", "/schedules/3/resume", nil)
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).ResumeSchedule(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCancelSchedule_AlreadyClosed(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("SetScheduleStatus", 3, models.ScheduleCancelled, (*time.Time)(nil)).Return(nil, storage.ErrScheduleClosed)
+
+	req := httptest.NewRequest("POST", "/schedules/3/cancel", nil)
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(mockDB, nil).CancelSchedule(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
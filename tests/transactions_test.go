@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTransactions_InvalidAccountID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/accounts/abc/transactions", nil)
+	req.SetPathValue("id", "abc")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).ListTransactions(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid account ID")
+}
+
+func TestListTransactions_InvalidFilters(t *testing.T) {
+	cases := map[string]string{
+		"sort=sideways":         "sort must be asc or desc",
+		"limit=0":               "limit must be between 1 and 200",
+		"limit=1000":            "limit must be between 1 and 200",
+		"cursor=not-a-cursor":   "invalid cursor",
+		"min_amount=1.005":      "invalid min_amount",
+		"from=yesterday":        "from must be an RFC 3339 timestamp",
+		"to=2025-01-01":         "to must be an RFC 3339 timestamp",
+		"max_amount=ten-dollar": "invalid max_amount",
+	}
+	for query, message := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1/transactions?"+query, nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		newTestHandler(nil, nil).ListTransactions(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Contains(t, rec.Body.String(), message, query)
+	}
+}
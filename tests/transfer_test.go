@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransfer_NegativeAmount(t *testing.T) {
+	transfer := models.Transfer{FromAccountID: 1, ToAccountID: 2, Amount: -10000}
+	reqBody, _ := json.Marshal(transfer)
+
+	req := httptest.NewRequest("POST", "/transfer", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).Transfer(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Transfer amount must be greater than zero")
+}
+
+func TestTransfer_SameAccount(t *testing.T) {
+	transfer := models.Transfer{FromAccountID: 1, ToAccountID: 1, Amount: 10000}
+	reqBody, _ := json.Marshal(transfer)
+
+	req := httptest.NewRequest("POST", "/transfer", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).Transfer(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Cannot transfer to the same account")
+}
+
+func TestTransfer_InvalidPayload(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/transfer", bytes.NewReader([]byte("{invalid json}")))
+	rec := httptest.NewRecorder()
+
+	newTestHandler(nil, nil).Transfer(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid request")
+}
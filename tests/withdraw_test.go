@@ -1,8 +1,8 @@
 package tests
 
 import (
-	"banking-ledger-service/internal/handlers"
 	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/products"
 	"banking-ledger-service/tests/mocks"
 	"bytes"
 	"encoding/json"
@@ -20,17 +20,17 @@ func TestWithdraw_Success(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 1000.00}, nil)
-	mockQueue.On("PublishMessage", mock.Anything).Return(nil)
+	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 100000, AvailableBalance: 100000}, nil)
+	mockQueue.On("Publish", "withdraw", "", mock.Anything).Return(&models.Operation{ID: 1, Type: "withdraw", Status: models.OperationQueued}, true, nil)
 
-	transaction := models.Transaction{AccountID: 1, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Withdrawal request sent to queue")
@@ -40,28 +40,28 @@ func TestWithdraw_Success(t *testing.T) {
 }
 
 func TestWithdraw_NegativeAmount(t *testing.T) {
-	transaction := models.Transaction{AccountID: 1, Amount: -500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: -50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(nil, nil).Withdraw(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Withdrawal amount must be greater than zero")
 }
 
 func TestWithdraw_ZeroAmount(t *testing.T) {
-	transaction := models.Transaction{AccountID: 1, Amount: 0.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 0}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(nil, nil).Withdraw(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Withdrawal amount must be greater than zero")
@@ -72,14 +72,14 @@ func TestWithdraw_NonExistentAccount(t *testing.T) {
 
 	mockDB.On("GetAccount", mock.Anything).Return(nil, errors.New("account not found"))
 
-	transaction := models.Transaction{AccountID: 99, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 99, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(mockDB, nil).Withdraw(rec, req)
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Account not found")
@@ -90,16 +90,16 @@ func TestWithdraw_NonExistentAccount(t *testing.T) {
 func TestWithdraw_InsufficientFunds(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 200.00}, nil)
+	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 20000, AvailableBalance: 20000}, nil)
 
-	transaction := models.Transaction{AccountID: 1, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(mockDB, nil).Withdraw(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Insufficient funds")
@@ -111,17 +111,17 @@ func TestWithdraw_QueueFailure(t *testing.T) {
 	mockDB := new(mocks.MockDB)
 	mockQueue := new(mocks.MockQueue)
 
-	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Balance: 1000.00}, nil)
-	mockQueue.On("PublishMessage", mock.Anything).Return(errors.New("queue failure"))
+	mockDB.On("GetAccount", mock.Anything).Return(&models.Account{ID: 1, Name: "John Doe", Status: models.AccountActive, ProductCode: products.Checking, Currency: "USD", Balance: 100000, AvailableBalance: 100000}, nil)
+	mockQueue.On("Publish", "withdraw", "", mock.Anything).Return(nil, false, errors.New("queue failure"))
 
-	transaction := models.Transaction{AccountID: 1, Amount: 500.00}
+	transaction := models.Transaction{AccountID: 1, Amount: 50000}
 	reqBody, _ := json.Marshal(transaction)
 
 	req := httptest.NewRequest("POST", "/withdraw", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlers.Withdraw(rec, req)
+	newTestHandler(mockDB, mockQueue).Withdraw(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Failed to queue withdrawal transaction")
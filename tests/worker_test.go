@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"banking-ledger-service/internal/models"
+	"banking-ledger-service/internal/queue"
+	"banking-ledger-service/internal/storage"
+	"banking-ledger-service/internal/worker"
+	"banking-ledger-service/tests/mocks"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestProcessor(mockDB *mocks.MockDB) *worker.Processor {
+	return worker.NewProcessor(mockDB, mockDB, mockDB)
+}
+
+func TestProcessTransaction_DepositSuccess(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "deposit", "operation:7").Return(nil)
+	mockDB.On("LogTransaction", 1, models.Money(50000), "deposit").Return()
+	mockDB.On("MarkOperationSucceeded", 7, 1).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "deposit", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_InsufficientFundsFailsOperation(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "withdraw", "operation:7").Return(storage.ErrInsufficientFunds)
+	mockDB.On("MarkOperationFailed", 7, "insufficient funds").Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "withdraw", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_DuplicateRequestSucceeds(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "deposit", "operation:7").Return(storage.ErrDuplicateRequest)
+	mockDB.On("MarkOperationSucceeded", 7, 1).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "deposit", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	// The duplicate must not be logged a second time
+	mockDB.AssertNotCalled(t, "LogTransaction", mock.Anything, mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_InvalidMessageIsDeadLettered(t *testing.T) {
+	msg := &mocks.MockDelivery{Payload: []byte(`{not json`)}
+	msg.On("DeadLetter", mock.MatchedBy(func(reason string) bool {
+		return assert.Contains(t, reason, "invalid message")
+	})).Return(nil)
+
+	newTestProcessor(new(mocks.MockDB)).ProcessTransaction(msg)
+
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_TransientErrorIsRetried(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "deposit", "operation:7").Return(errors.New("connection refused"))
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "deposit", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("Retry", "connection refused").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_ExhaustedRetriesAreDeadLettered(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", 1, models.Money(50000), "deposit", "operation:7").Return(errors.New("connection refused"))
+	mockDB.On("MarkOperationFailed", 7, "connection refused").Return(nil)
+
+	msg := &mocks.MockDelivery{
+		Payload: []byte(`{"type": "deposit", "operation_id": 7, "account_id": 1, "amount": 500}`),
+		Retries: queue.MaxRetries,
+	}
+	msg.On("DeadLetter", "connection refused").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_PanicIsRecovered(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 7).Return(nil)
+	mockDB.On("UpdateBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("boom")
+	})
+	mockDB.On("MarkOperationFailed", 7, "panic: boom").Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"type": "deposit", "operation_id": 7, "account_id": 1, "amount": 500}`)}
+	msg.On("DeadLetter", "panic: boom").Return(nil)
+
+	assert.NotPanics(t, func() { newTestProcessor(mockDB).ProcessTransaction(msg) })
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessorRun_KeepsPerAccountOrder(t *testing.T) {
+	const perAccount = 20
+
+	var mu sync.Mutex
+	applied := map[int][]models.Money{}
+
+	mockDB := new(mocks.MockDB)
+	mockDB.On("UpdateBalance", mock.Anything, mock.Anything, "deposit", "").Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		accountID := args.Int(0)
+		applied[accountID] = append(applied[accountID], args.Get(1).(models.Money))
+	}).Return(nil)
+	mockDB.On("LogTransaction", mock.Anything, mock.Anything, "deposit").Return()
+
+	deliveries := make(chan queue.Delivery)
+	go func() {
+		defer close(deliveries)
+		for i := 1; i <= perAccount; i++ {
+			for accountID := 1; accountID <= 3; accountID++ {
+				msg := &mocks.MockDelivery{Payload: []byte(fmt.Sprintf(`{"type": "deposit", "account_id": %d, "amount": "0.%02d"}`, accountID, i))}
+				msg.On("Ack").Return(nil)
+				deliveries <- msg
+			}
+		}
+	}()
+
+	newTestProcessor(mockDB).Run(deliveries, 4, 8)
+
+	for accountID := 1; accountID <= 3; accountID++ {
+		amounts := applied[accountID]
+		assert.Len(t, amounts, perAccount)
+		for i, amount := range amounts {
+			assert.Equal(t, models.Money(i+1), amount, "account %d out of order", accountID)
+		}
+	}
+}
+
+func TestProcessorRun_SlowAccountDoesNotStallOthers(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{}, 10)
+
+	// Account 1's first deposit blocks its lane until released
+	mockDB := new(mocks.MockDB)
+	mockDB.On("UpdateBalance", 1, models.Money(1), "deposit", "").Run(func(mock.Arguments) { <-release }).Return(nil)
+	mockDB.On("UpdateBalance", mock.Anything, mock.Anything, "deposit", "").Run(func(mock.Arguments) {
+		done <- struct{}{}
+	}).Return(nil)
+	mockDB.On("LogTransaction", mock.Anything, mock.Anything, "deposit").Return()
+
+	deliveries := make(chan queue.Delivery)
+	finished := make(chan struct{})
+	go func() {
+		newTestProcessor(mockDB).Run(deliveries, 4, 8)
+		close(finished)
+	}()
+
+	deliver := func(accountID int, amount string) {
+		msg := &mocks.MockDelivery{Payload: []byte(fmt.Sprintf(`{"type": "deposit", "account_id": %d, "amount": "%s"}`, accountID, amount))}
+		msg.On("Ack").Return(nil)
+		deliveries <- msg
+	}
+	go func() {
+		deliver(1, "0.01")
+		for i := 0; i < 3; i++ {
+			deliver(1, "0.02")
+		}
+		deliver(2, "0.03")
+		<-release
+		close(deliveries)
+	}()
+
+	// Account 2 is processed while account 1 is still busy
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deposit to account 2 waited for account 1")
+	}
+
+	close(release)
+	<-finished
+}
+
+func TestProcessorRun_TransferOrderedWithCreditedAccount(t *testing.T) {
+	var mu sync.Mutex
+	var credited, creditedBeforeWithdrawal bool
+
+	// The transfer into account 2 is slow; the withdrawal from account 2 that
+	// follows it must still see it applied
+	mockDB := new(mocks.MockDB)
+	mockDB.On("Transfer", 1, 2, models.Money(1000), "").Run(func(mock.Arguments) {
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		credited = true
+	}).Return(nil)
+	mockDB.On("UpdateBalance", 2, models.Money(1000), "withdraw", "").Run(func(mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		creditedBeforeWithdrawal = credited
+	}).Return(nil)
+	mockDB.On("LogTransaction", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	transfer := &mocks.MockDelivery{Payload: []byte(`{"type": "transfer", "from_account_id": 1, "to_account_id": 2, "amount": "10.00"}`)}
+	transfer.On("Ack").Return(nil)
+	withdraw := &mocks.MockDelivery{Payload: []byte(`{"type": "withdraw", "account_id": 2, "amount": "10.00"}`)}
+	withdraw.On("Ack").Return(nil)
+
+	deliveries := make(chan queue.Delivery, 2)
+	deliveries <- transfer
+	deliveries <- withdraw
+	close(deliveries)
+
+	newTestProcessor(mockDB).Run(deliveries, 4, 8)
+
+	mockDB.AssertExpectations(t)
+	transfer.AssertExpectations(t)
+	withdraw.AssertExpectations(t)
+	assert.True(t, creditedBeforeWithdrawal, "withdrawal ran before the transfer into the account")
+}
+
+func TestProcessTransaction_VersionedEnvelope(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 9).Return(nil)
+	mockDB.On("Transfer", 1, 2, models.Money(1000), "operation:9").Return(nil)
+	mockDB.On("LogTransaction", 1, models.Money(1000), "transfer_out").Return()
+	mockDB.On("LogTransaction", 2, models.Money(1000), "transfer_in").Return()
+	mockDB.On("MarkOperationSucceeded", 9, 1).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"version": 2, "message_id": "m-1", "type": "transfer", "operation_id": 9,
+		"payload": {"from_account_id": 1, "to_account_id": 2, "amount": "10.00"}}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_InvalidPayloadFailsOperation(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationFailed", 9, "invalid message: amount must be greater than zero").Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"version": 2, "type": "deposit", "operation_id": 9, "payload": {"account_id": 1, "amount": 0}}`)}
+	msg.On("DeadLetter", "invalid message: amount must be greater than zero").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}
+
+func TestProcessTransaction_ReversalIsLogged(t *testing.T) {
+	mockDB := new(mocks.MockDB)
+	mockDB.On("MarkOperationProcessing", 4).Return(nil)
+	mockDB.On("ReverseTransaction", 5, "duplicate", "ops", "operation:4").
+		Return(&models.Transaction{ID: 6, AccountID: 1, Amount: 2000, Type: "reversal"}, nil)
+	mockDB.On("LogTransaction", 1, models.Money(2000), "reversal").Return()
+	mockDB.On("MarkOperationSucceeded", 4, 1).Return(nil)
+
+	msg := &mocks.MockDelivery{Payload: []byte(`{"version": 2, "type": "reversal", "operation_id": 4,
+		"payload": {"transaction_id": 5, "account_id": 1, "reason": "duplicate", "actor": "ops"}}`)}
+	msg.On("Ack").Return(nil)
+
+	newTestProcessor(mockDB).ProcessTransaction(msg)
+
+	mockDB.AssertExpectations(t)
+	msg.AssertExpectations(t)
+}